@@ -33,11 +33,19 @@ type EmailConfig struct {
 	IsTLS    bool   `json:"is_tls"`
 }
 
+type SMSConfig struct {
+	Provider string `json:"provider"`
+	ApiURL   string `json:"api_url"`
+	ApiKey   string `json:"api_key"`
+	Sender   string `json:"sender"`
+}
+
 type Config struct {
 	App      App         `json:"app"`
 	Psql     PgsqlDB     `json:"psql"`
 	RabbitMQ RabbitMQ    `json:"rabbitmq"`
 	Email    EmailConfig `json:"email"`
+	SMS      SMSConfig   `json:"sms"`
 }
 
 func NewConfig() *Config {
@@ -69,5 +77,11 @@ func NewConfig() *Config {
 			Sending:  viper.GetString("EMAIL_SENDING"),
 			IsTLS:    viper.GetBool("EMAIL_TLS"),
 		},
+		SMS: SMSConfig{
+			Provider: viper.GetString("SMS_PROVIDER"),
+			ApiURL:   viper.GetString("SMS_API_URL"),
+			ApiKey:   viper.GetString("SMS_API_KEY"),
+			Sender:   viper.GetString("SMS_SENDER"),
+		},
 	}
 }
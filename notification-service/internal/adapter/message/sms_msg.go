@@ -0,0 +1,109 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+
+	"notification-service/config"
+)
+
+type MessageSMSInterface interface {
+	SendSMS(to, body string) error
+}
+
+// SentSMS is a message recorded by the fake provider.
+type SentSMS struct {
+	To   string
+	Body string
+}
+
+// FakeSMS does not send anything. It logs each message and keeps it in
+// memory, for local development and tests.
+type FakeSMS struct {
+	mu   sync.Mutex
+	sent []SentSMS
+}
+
+// SendSMS implements MessageSMSInterface.
+func (f *FakeSMS) SendSMS(to, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sent = append(f.sent, SentSMS{To: to, Body: body})
+	log.Infof("[FakeSMS] to %s: %s", to, body)
+	return nil
+}
+
+// Sent returns the messages sent so far.
+func (f *FakeSMS) Sent() []SentSMS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]SentSMS(nil), f.sent...)
+}
+
+// httpSMS posts messages as JSON to an SMS gateway.
+type httpSMS struct {
+	client *http.Client
+	url    string
+	apiKey string
+	sender string
+}
+
+// SendSMS implements MessageSMSInterface.
+func (h *httpSMS) SendSMS(to, body string) error {
+	payload, err := json.Marshal(map[string]string{
+		"from": h.sender,
+		"to":   to,
+		"text": body,
+	})
+	if err != nil {
+		log.Errorf("[SendSMS-1] error: %v", err)
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		log.Errorf("[SendSMS-2] error: %v", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+h.apiKey)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		log.Errorf("[SendSMS-3] error: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("sms gateway returned %s", resp.Status)
+		log.Errorf("[SendSMS-4] error: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// NewMessageSMS returns the provider named by SMS_PROVIDER, falling back to
+// the fake one.
+func NewMessageSMS(cfg *config.Config) MessageSMSInterface {
+	switch cfg.SMS.Provider {
+	case "http":
+		return &httpSMS{
+			client: &http.Client{Timeout: 10 * time.Second},
+			url:    cfg.SMS.ApiURL,
+			apiKey: cfg.SMS.ApiKey,
+			sender: cfg.SMS.Sender,
+		}
+	default:
+		return &FakeSMS{}
+	}
+}
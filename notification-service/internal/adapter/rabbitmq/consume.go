@@ -18,6 +18,7 @@ type ConsumeRabbitmqInterface interface {
 type consumeRabbitMQ struct {
 	conn         *amqp.Connection
 	emailService message.MessageEmailInterfface
+	smsService   message.MessageSMSInterface
 }
 
 // ConsumeMessage implements ConsumeRabbitmqInterface.
@@ -37,26 +38,36 @@ func (c consumeRabbitMQ) ConsumeMessage(queueName string) error {
 	}
 
 	for msg := range msgs {
-		var notificationEntity entity.NotificationEntity
 		log.Infof("[ConsumeMessage] received message: %s", msg.Body)
-		if err = json.Unmarshal(msg.Body, &notificationEntity); err != nil {
-			log.Errorf("[ConsumeMessage-3] failed to unmarshal message: %v", err)
-			continue
-		}
+		c.handle(queueName, msg.Body)
+	}
 
-		err = c.emailService.SendEmailNotif(notificationEntity.Email, queueName, notificationEntity.Message)
-		if err != nil {
-			log.Errorf("[ConsumeMessage-4] failed to send email: %v", err)
-			continue
-		}
+	return nil
+
+}
 
+// handle sends one notification by SMS or email, depending on its channel.
+// Failures are only logged, the message has already been acked.
+func (c consumeRabbitMQ) handle(queueName string, body []byte) {
+	var notificationEntity entity.NotificationEntity
+	if err := json.Unmarshal(body, &notificationEntity); err != nil {
+		log.Errorf("[ConsumeMessage-3] failed to unmarshal message: %v", err)
+		return
 	}
 
-	return nil
+	if notificationEntity.Channel == entity.ChannelSMS {
+		if err := c.smsService.SendSMS(notificationEntity.Phone, notificationEntity.Message); err != nil {
+			log.Errorf("[ConsumeMessage-5] failed to send sms: %v", err)
+		}
+		return
+	}
 
+	if err := c.emailService.SendEmailNotif(notificationEntity.Email, queueName, notificationEntity.Message); err != nil {
+		log.Errorf("[ConsumeMessage-4] failed to send email: %v", err)
+	}
 }
 
-func NewConsumeRabbitMQ(cfg *config.Config, emailService message.MessageEmailInterfface) ConsumeRabbitmqInterface {
+func NewConsumeRabbitMQ(cfg *config.Config, emailService message.MessageEmailInterfface, smsService message.MessageSMSInterface) ConsumeRabbitmqInterface {
 
 	newConnect, err := cfg.NewRabbitMQ()
 	if err != nil {
@@ -67,5 +78,6 @@ func NewConsumeRabbitMQ(cfg *config.Config, emailService message.MessageEmailInt
 	return &consumeRabbitMQ{
 		conn:         newConnect,
 		emailService: emailService,
+		smsService:   smsService,
 	}
 }
@@ -0,0 +1,68 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	"notification-service/internal/adapter/message"
+)
+
+type sentEmail struct {
+	to, subject, body string
+}
+
+type fakeEmail struct {
+	sent []sentEmail
+}
+
+func (f *fakeEmail) SendEmailNotif(to, subject, body string) error {
+	f.sent = append(f.sent, sentEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// TestHandleSendsSMS feeds the payload user-service's message.SMSEvent
+// produces for a phone verification code through the fake SMS provider.
+func TestHandleSendsSMS(t *testing.T) {
+	sms := &message.FakeSMS{}
+	email := &fakeEmail{}
+	c := consumeRabbitMQ{emailService: email, smsService: sms}
+
+	c.handle("notifications", []byte(`{"channel":"sms","phone":"+6281234567890","msg":"Sayur verification code: 123456. Valid for 10 minutes."}`))
+
+	sent := sms.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d sms, want 1", len(sent))
+	}
+	if sent[0].To != "+6281234567890" || sent[0].Body != "Sayur verification code: 123456. Valid for 10 minutes." {
+		t.Fatalf("sent %+v", sent[0])
+	}
+	if len(email.sent) != 0 {
+		t.Fatalf("sent %d emails for an sms", len(email.sent))
+	}
+}
+
+func TestHandleSendsEmail(t *testing.T) {
+	sms := &message.FakeSMS{}
+	email := &fakeEmail{}
+	c := consumeRabbitMQ{emailService: email, smsService: sms}
+
+	c.handle("notifications", []byte(`{"email":"jane@example.com","msg":"hello"}`))
+
+	if len(email.sent) != 1 || email.sent[0] != (sentEmail{to: "jane@example.com", subject: "notifications", body: "hello"}) {
+		t.Fatalf("sent emails %+v", email.sent)
+	}
+	if len(sms.Sent()) != 0 {
+		t.Fatalf("sent %d sms for an email", len(sms.Sent()))
+	}
+}
+
+func TestHandleDropsInvalidMessages(t *testing.T) {
+	sms := &message.FakeSMS{}
+	email := &fakeEmail{}
+	c := consumeRabbitMQ{emailService: email, smsService: sms}
+
+	c.handle("notifications", []byte(`not json`))
+
+	if len(email.sent) != 0 || len(sms.Sent()) != 0 {
+		t.Fatalf("sent a notification for an invalid message")
+	}
+}
@@ -12,7 +12,8 @@ import (
 func RunServer() {
 	cfg := config.NewConfig()
 	emailMessage := message.NewMessageEmail(cfg)
-	rabbitMQAdapter := rabbitmq.NewConsumeRabbitMQ(cfg, emailMessage)
+	smsMessage := message.NewMessageSMS(cfg)
+	rabbitMQAdapter := rabbitmq.NewConsumeRabbitMQ(cfg, emailMessage, smsMessage)
 
 	e := echo.New()
 
@@ -1,6 +1,11 @@
 package entity
 
 type NotificationEntity struct {
+	// Channel is "sms" for text messages; anything else is sent by email.
+	Channel string
 	Email   string
-	Message string
+	Phone   string
+	Message string `json:"msg"`
 }
+
+const ChannelSMS = "sms"
@@ -37,6 +37,57 @@ func initConfig() {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("JWT_ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("JWT_REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS_PER_IP", 20)
+	viper.SetDefault("LOGIN_ATTEMPT_WINDOW", "15m")
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("TWO_FACTOR_ISSUER", "Sayur")
+	viper.SetDefault("TOKEN_RESEND_COOLDOWN", "1m")
+	viper.SetDefault("URL_VERIFY_ACCOUNT", "http://localhost:8080")
+	viper.SetDefault("URL_MAGIC_LINK", "http://localhost:8080")
+	viper.SetDefault("PASSWORDLESS_TTL", "10m")
+	viper.SetDefault("PASSWORDLESS_MAX_ATTEMPTS", 5)
+	viper.SetDefault("PHONE_OTP_TTL", "5m")
+	viper.SetDefault("PHONE_OTP_MAX_ATTEMPTS", 5)
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MIN_CHAR_CLASSES", 3)
+	viper.SetDefault("PASSWORD_HISTORY", 5)
+	viper.SetDefault("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	viper.SetDefault("PASSWORD_BCRYPT_COST", 12)
+	viper.SetDefault("PASSWORD_ARGON2_MEMORY", 19456)
+	viper.SetDefault("PASSWORD_ARGON2_ITERATIONS", 2)
+	viper.SetDefault("PASSWORD_ARGON2_PARALLELISM", 1)
+	viper.SetDefault("ACCOUNT_DELETION_GRACE_PERIOD", "720h")
+	viper.SetDefault("ACCOUNT_PURGE_INTERVAL", "1h")
+	viper.SetDefault("ACCOUNT_REAUTH_WINDOW", "5m")
+	viper.SetDefault("STORAGE_DRIVER", "supabase")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("LOCAL_STORAGE_DIR", "./storage")
+	viper.SetDefault("LOCAL_STORAGE_URL", "http://localhost:8080/storage")
+	viper.SetDefault("IMAGE_MAX_UPLOAD_SIZE", 5242880)
+	viper.SetDefault("IMAGE_MAX_PIXELS", 40000000)
+	viper.SetDefault("IMAGE_ORIGINAL_SIZE", 2048)
+	viper.SetDefault("IMAGE_MEDIUM_SIZE", 512)
+	viper.SetDefault("IMAGE_THUMBNAIL_SIZE", 128)
+	viper.SetDefault("IMAGE_UPLOAD_URL_TTL", "15m")
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "2s")
+	viper.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	viper.SetDefault("OUTBOX_MAX_BACKOFF", "10m")
+	viper.SetDefault("OUTBOX_RETENTION", "168h")
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_PUBLIC_REQUESTS", 20)
+	viper.SetDefault("RATE_LIMIT_PUBLIC_PERIOD", "1m")
+	viper.SetDefault("RATE_LIMIT_EMAIL_REQUESTS", 5)
+	viper.SetDefault("RATE_LIMIT_EMAIL_PERIOD", "15m")
+	viper.SetDefault("RATE_LIMIT_AUTH_REQUESTS", 120)
+	viper.SetDefault("RATE_LIMIT_AUTH_PERIOD", "1m")
+	viper.SetDefault("RATE_LIMIT_ADMIN_REQUESTS", 300)
+	viper.SetDefault("RATE_LIMIT_ADMIN_PERIOD", "1m")
+	viper.SetDefault("RATE_LIMIT_SMS_REQUESTS", 3)
+	viper.SetDefault("RATE_LIMIT_SMS_PERIOD", "15m")
+
 	if err := viper.ReadInConfig(); err != nil {
 		fmt.Fprintln(os.Stderr, "using config file:", viper.ConfigFileUsed())
 	}
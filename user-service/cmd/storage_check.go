@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"user-service/config"
+	"user-service/internal/adapter/storage"
+	"user-service/internal/adapter/storage/conformance"
+)
+
+var storageCheckCmd = &cobra.Command{
+	Use:   "storage-check",
+	Short: "run the storage conformance checks against the configured backend",
+	Long:  "Uploads, fetches and deletes a few objects under conformance/ using STORAGE_DRIVER and its settings, and exits non-zero if any check fails.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.NewConfig()
+
+		store, err := storage.New(cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		client := &http.Client{Timeout: 30 * time.Second}
+		if server, ok := store.(storage.Server); ok {
+			client.Transport = conformance.HandlerTransport(server)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		failed := false
+		for _, result := range conformance.Run(ctx, store, client, "conformance/"+uuid.New().String()) {
+			if result.Err != nil {
+				failed = true
+				fmt.Printf("FAIL %s: %v\n", result.Name, result.Err)
+				continue
+			}
+			fmt.Printf("ok   %s\n", result.Name)
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(storageCheckCmd)
+}
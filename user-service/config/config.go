@@ -1,15 +1,30 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type App struct {
 	AppPort string `json:"app_port"`
 	AppEnv  string `json:"app_env"`
 
-	JwtSecretKey string `json:"jwt_secret_key"`
-	JwtIssuer    string `json:"jwt_issuer"`
+	// TrustedProxies are the CIDRs allowed to set X-Forwarded-For. Empty
+	// means the service is reached directly and the header is ignored.
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	JwtSecretKey       string        `json:"jwt_secret_key"`
+	JwtIssuer          string        `json:"jwt_issuer"`
+	JwtAccessTokenTTL  time.Duration `json:"jwt_access_token_ttl"`
+	JwtRefreshTokenTTL time.Duration `json:"jwt_refresh_token_ttl"`
+	JwtKeysDir         string        `json:"jwt_keys_dir"`
+	JwtActiveKID       string        `json:"jwt_active_kid"`
 
 	UrlForgotPassword string `json:"url_forgot_password"`
+	UrlVerifyAccount  string `json:"url_verify_account"`
+	UrlMagicLink      string `json:"url_magic_link"`
 }
 
 type PgsqlDB struct {
@@ -35,21 +50,134 @@ type Supabase struct {
 	Bucket string `json:"bucket"`
 }
 
+type S3Storage struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Bucket    string `json:"bucket"`
+	UseSSL    bool   `json:"use_ssl"`
+	PublicURL string `json:"public_url"`
+}
+
+type LocalStorage struct {
+	Dir        string `json:"dir"`
+	PublicURL  string `json:"public_url"`
+	SigningKey string `json:"signing_key"`
+}
+
+// Storage selects the object storage backend: supabase, s3 or local.
+type Storage struct {
+	Driver   string       `json:"driver"`
+	Supabase Supabase     `json:"supabase"`
+	S3       S3Storage    `json:"s3"`
+	Local    LocalStorage `json:"local"`
+}
+
+type Security struct {
+	LoginMaxAttempts      int           `json:"login_max_attempts"`
+	LoginMaxAttemptsPerIP int           `json:"login_max_attempts_per_ip"`
+	LoginAttemptWindow    time.Duration `json:"login_attempt_window"`
+	LoginLockoutDuration  time.Duration `json:"login_lockout_duration"`
+	TwoFactorIssuer       string        `json:"two_factor_issuer"`
+	TokenResendCooldown   time.Duration `json:"token_resend_cooldown"`
+	PasswordlessTTL       time.Duration `json:"passwordless_ttl"`
+	PasswordlessAttempts  int           `json:"passwordless_attempts"`
+	PhoneOTPTTL           time.Duration `json:"phone_otp_ttl"`
+	PhoneOTPAttempts      int           `json:"phone_otp_attempts"`
+}
+
+type PasswordPolicy struct {
+	MinLength      int    `json:"min_length"`
+	MinCharClasses int    `json:"min_char_classes"`
+	History        int    `json:"history"`
+	BreachedDir    string `json:"breached_dir"`
+}
+
+type PasswordHash struct {
+	Algorithm         string `json:"algorithm"`
+	BcryptCost        int    `json:"bcrypt_cost"`
+	Argon2Memory      uint32 `json:"argon2_memory"`
+	Argon2Iterations  uint32 `json:"argon2_iterations"`
+	Argon2Parallelism uint8  `json:"argon2_parallelism"`
+}
+
+type OIDCProvider struct {
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+}
+
+type RateLimitRule struct {
+	Requests int           `json:"requests"`
+	Period   time.Duration `json:"period"`
+}
+
+type RateLimit struct {
+	Enabled bool                     `json:"enabled"`
+	Rules   map[string]RateLimitRule `json:"rules"`
+}
+
+type Account struct {
+	DeletionGracePeriod time.Duration `json:"deletion_grace_period"`
+	PurgeInterval       time.Duration `json:"purge_interval"`
+	// ReauthWindow is how recently a session must have signed in to delete
+	// the account without the password.
+	ReauthWindow time.Duration `json:"reauth_window"`
+}
+
+// Outbox tunes the relay that publishes stored events to RabbitMQ.
+type Outbox struct {
+	PollInterval time.Duration `json:"poll_interval"`
+	BatchSize    int           `json:"batch_size"`
+	MaxBackoff   time.Duration `json:"max_backoff"`
+	Retention    time.Duration `json:"retention"`
+}
+
+type Image struct {
+	MaxUploadSize int64 `json:"max_upload_size"`
+	MaxPixels     int   `json:"max_pixels"`
+	OriginalSize  int   `json:"original_size"`
+	MediumSize    int   `json:"medium_size"`
+	ThumbnailSize int   `json:"thumbnail_size"`
+
+	UploadURLTTL time.Duration `json:"upload_url_ttl"`
+}
+
 type Config struct {
-	App      App      `json:"app"`
-	Psql     PgsqlDB  `json:"psql"`
-	RabbitMQ RabbitMQ `json:"rabbitmq"`
-	Storage  Supabase `json:"supabase"`
+	App       App       `json:"app"`
+	Psql      PgsqlDB   `json:"psql"`
+	RabbitMQ  RabbitMQ  `json:"rabbitmq"`
+	Storage   Storage   `json:"storage"`
+	Security  Security  `json:"security"`
+	RateLimit RateLimit `json:"rate_limit"`
+	Account   Account   `json:"account"`
+	Image     Image     `json:"image"`
+	Outbox    Outbox    `json:"outbox"`
+
+	PasswordPolicy PasswordPolicy `json:"password_policy"`
+	PasswordHash   PasswordHash   `json:"password_hash"`
+
+	OIDC map[string]OIDCProvider `json:"oidc"`
 }
 
 func NewConfig() *Config {
 	return &Config{
 		App: App{
-			AppPort:           viper.GetString("APP_PORT"),
-			AppEnv:            viper.GetString("APP_ENV"),
-			JwtSecretKey:      viper.GetString("JWT_SECRET_KEY"),
-			JwtIssuer:         viper.GetString("JWT_ISSUER"),
-			UrlForgotPassword: viper.GetString("URL_FORGOT_PASSWORD"),
+			AppPort:            viper.GetString("APP_PORT"),
+			AppEnv:             viper.GetString("APP_ENV"),
+			TrustedProxies:     splitList(viper.GetString("TRUSTED_PROXIES")),
+			JwtSecretKey:       viper.GetString("JWT_SECRET_KEY"),
+			JwtIssuer:          viper.GetString("JWT_ISSUER"),
+			JwtAccessTokenTTL:  viper.GetDuration("JWT_ACCESS_TOKEN_TTL"),
+			JwtRefreshTokenTTL: viper.GetDuration("JWT_REFRESH_TOKEN_TTL"),
+			JwtKeysDir:         viper.GetString("JWT_KEYS_DIR"),
+			JwtActiveKID:       viper.GetString("JWT_ACTIVE_KID"),
+			UrlForgotPassword:  viper.GetString("URL_FORGOT_PASSWORD"),
+			UrlVerifyAccount:   viper.GetString("URL_VERIFY_ACCOUNT"),
+			UrlMagicLink:       viper.GetString("URL_MAGIC_LINK"),
 		}, // Asumsi struct App tidak memiliki field yang perlu diinisialisasi di sini
 		Psql: PgsqlDB{
 			Host:      viper.GetString("DATABASE_HOST"),
@@ -66,10 +194,123 @@ func NewConfig() *Config {
 			User:     viper.GetString("RABBITMQ_USER"),
 			Password: viper.GetString("RABBITMQ_PASSWORD"),
 		},
-		Storage: Supabase{
-			URL:    viper.GetString("SUPABASE_STORAGE_URL"),
-			Key:    viper.GetString("SUPABASE_STORAGE_KEY"),
-			Bucket: viper.GetString("SUPABASE_STORAGE_BUCKET"),
+		Storage: Storage{
+			Driver: viper.GetString("STORAGE_DRIVER"),
+			Supabase: Supabase{
+				URL:    viper.GetString("SUPABASE_STORAGE_URL"),
+				Key:    viper.GetString("SUPABASE_STORAGE_KEY"),
+				Bucket: viper.GetString("SUPABASE_STORAGE_BUCKET"),
+			},
+			S3: S3Storage{
+				Endpoint:  viper.GetString("S3_ENDPOINT"),
+				Region:    viper.GetString("S3_REGION"),
+				AccessKey: viper.GetString("S3_ACCESS_KEY"),
+				SecretKey: viper.GetString("S3_SECRET_KEY"),
+				Bucket:    viper.GetString("S3_BUCKET"),
+				UseSSL:    viper.GetBool("S3_USE_SSL"),
+				PublicURL: viper.GetString("S3_PUBLIC_URL"),
+			},
+			Local: LocalStorage{
+				Dir:        viper.GetString("LOCAL_STORAGE_DIR"),
+				PublicURL:  viper.GetString("LOCAL_STORAGE_URL"),
+				SigningKey: viper.GetString("LOCAL_STORAGE_SIGNING_KEY"),
+			},
+		},
+		Security: Security{
+			LoginMaxAttempts:      viper.GetInt("LOGIN_MAX_ATTEMPTS"),
+			LoginMaxAttemptsPerIP: viper.GetInt("LOGIN_MAX_ATTEMPTS_PER_IP"),
+			LoginAttemptWindow:    viper.GetDuration("LOGIN_ATTEMPT_WINDOW"),
+			LoginLockoutDuration:  viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+			TwoFactorIssuer:       viper.GetString("TWO_FACTOR_ISSUER"),
+			TokenResendCooldown:   viper.GetDuration("TOKEN_RESEND_COOLDOWN"),
+			PasswordlessTTL:       viper.GetDuration("PASSWORDLESS_TTL"),
+			PasswordlessAttempts:  viper.GetInt("PASSWORDLESS_MAX_ATTEMPTS"),
+			PhoneOTPTTL:           viper.GetDuration("PHONE_OTP_TTL"),
+			PhoneOTPAttempts:      viper.GetInt("PHONE_OTP_MAX_ATTEMPTS"),
+		},
+		RateLimit: RateLimit{
+			Enabled: viper.GetBool("RATE_LIMIT_ENABLED"),
+			Rules: map[string]RateLimitRule{
+				"public": rateLimitRule("PUBLIC"),
+				"email":  rateLimitRule("EMAIL"),
+				"auth":   rateLimitRule("AUTH"),
+				"admin":  rateLimitRule("ADMIN"),
+				"sms":    rateLimitRule("SMS"),
+			},
+		},
+		Account: Account{
+			DeletionGracePeriod: viper.GetDuration("ACCOUNT_DELETION_GRACE_PERIOD"),
+			PurgeInterval:       viper.GetDuration("ACCOUNT_PURGE_INTERVAL"),
+			ReauthWindow:        viper.GetDuration("ACCOUNT_REAUTH_WINDOW"),
+		},
+		Image: Image{
+			MaxUploadSize: viper.GetInt64("IMAGE_MAX_UPLOAD_SIZE"),
+			MaxPixels:     viper.GetInt("IMAGE_MAX_PIXELS"),
+			OriginalSize:  viper.GetInt("IMAGE_ORIGINAL_SIZE"),
+			MediumSize:    viper.GetInt("IMAGE_MEDIUM_SIZE"),
+			ThumbnailSize: viper.GetInt("IMAGE_THUMBNAIL_SIZE"),
+			UploadURLTTL:  viper.GetDuration("IMAGE_UPLOAD_URL_TTL"),
+		},
+		Outbox: Outbox{
+			PollInterval: viper.GetDuration("OUTBOX_POLL_INTERVAL"),
+			BatchSize:    viper.GetInt("OUTBOX_BATCH_SIZE"),
+			MaxBackoff:   viper.GetDuration("OUTBOX_MAX_BACKOFF"),
+			Retention:    viper.GetDuration("OUTBOX_RETENTION"),
+		},
+		PasswordPolicy: PasswordPolicy{
+			MinLength:      viper.GetInt("PASSWORD_MIN_LENGTH"),
+			MinCharClasses: viper.GetInt("PASSWORD_MIN_CHAR_CLASSES"),
+			History:        viper.GetInt("PASSWORD_HISTORY"),
+			BreachedDir:    viper.GetString("BREACHED_PASSWORDS_DIR"),
+		},
+		PasswordHash: PasswordHash{
+			Algorithm:         viper.GetString("PASSWORD_HASH_ALGORITHM"),
+			BcryptCost:        viper.GetInt("PASSWORD_BCRYPT_COST"),
+			Argon2Memory:      viper.GetUint32("PASSWORD_ARGON2_MEMORY"),
+			Argon2Iterations:  viper.GetUint32("PASSWORD_ARGON2_ITERATIONS"),
+			Argon2Parallelism: uint8(viper.GetUint("PASSWORD_ARGON2_PARALLELISM")),
 		},
+		OIDC: oidcProviders(),
+	}
+}
+
+// oidcProviders reads OIDC_<NAME>_* for every name listed in OIDC_PROVIDERS.
+func oidcProviders() map[string]OIDCProvider {
+	providers := map[string]OIDCProvider{}
+
+	for _, name := range strings.Split(viper.GetString("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers[name] = OIDCProvider{
+			Issuer:       viper.GetString(prefix + "ISSUER"),
+			ClientID:     viper.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: viper.GetString(prefix + "CLIENT_SECRET"),
+			RedirectURL:  viper.GetString(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(viper.GetString(prefix + "SCOPES")),
+		}
+	}
+
+	return providers
+}
+
+func rateLimitRule(group string) RateLimitRule {
+	return RateLimitRule{
+		Requests: viper.GetInt("RATE_LIMIT_" + group + "_REQUESTS"),
+		Period:   viper.GetDuration("RATE_LIMIT_" + group + "_PERIOD"),
+	}
+}
+
+// splitList splits a comma separated setting, dropping empty entries.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -0,0 +1,10 @@
+-- migrate:up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_suspended boolean DEFAULT FALSE;
+
+CREATE INDEX idx_users_is_suspended ON users(is_suspended);
+CREATE INDEX idx_users_deleted_at ON users(deleted_at);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DROP INDEX IF EXISTS idx_users_is_suspended;
+ALTER TABLE users DROP COLUMN IF EXISTS is_suspended;
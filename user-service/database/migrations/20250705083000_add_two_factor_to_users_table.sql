@@ -0,0 +1,23 @@
+-- migrate:up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_secret VARCHAR(64) NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_enabled boolean DEFAULT FALSE;
+
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS require_two_factor boolean DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL,
+    deleted_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+
+-- migrate:down
+DROP TABLE IF EXISTS "user_recovery_codes";
+ALTER TABLE roles DROP COLUMN IF EXISTS require_two_factor;
+ALTER TABLE users DROP COLUMN IF EXISTS two_factor_enabled;
+ALTER TABLE users DROP COLUMN IF EXISTS two_factor_secret;
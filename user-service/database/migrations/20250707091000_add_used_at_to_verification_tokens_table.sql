@@ -0,0 +1,13 @@
+-- migrate:up
+ALTER TABLE verification_tokens ADD COLUMN IF NOT EXISTS used_at TIMESTAMP NULL;
+
+-- Only live tokens must be unique: a code hash can recur once the earlier
+-- token is used or replaced.
+CREATE UNIQUE INDEX idx_verification_tokens_token ON verification_tokens(token)
+    WHERE deleted_at IS NULL AND used_at IS NULL;
+CREATE INDEX idx_verification_tokens_user_id_token_type ON verification_tokens(user_id, token_type);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_verification_tokens_user_id_token_type;
+DROP INDEX IF EXISTS idx_verification_tokens_token;
+ALTER TABLE verification_tokens DROP COLUMN IF EXISTS used_at;
@@ -0,0 +1,12 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS password_histories (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_histories_user_id ON password_histories(user_id, created_at);
+
+-- migrate:down
+DROP TABLE IF EXISTS "password_histories";
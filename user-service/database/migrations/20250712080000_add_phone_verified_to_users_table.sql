@@ -0,0 +1,5 @@
+-- migrate:up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified boolean DEFAULT FALSE;
+
+-- migrate:down
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified;
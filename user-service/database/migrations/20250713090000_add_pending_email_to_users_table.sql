@@ -0,0 +1,5 @@
+-- migrate:up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255) NULL;
+
+-- migrate:down
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
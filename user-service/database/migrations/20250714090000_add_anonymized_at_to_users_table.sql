@@ -0,0 +1,8 @@
+-- migrate:up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP NULL;
+
+CREATE INDEX idx_users_deleted_at_anonymized_at ON users(deleted_at, anonymized_at);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_users_deleted_at_anonymized_at;
+ALTER TABLE users DROP COLUMN IF EXISTS anonymized_at;
@@ -0,0 +1,26 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS user_addresses (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label VARCHAR(50) NOT NULL,
+    recipient_name VARCHAR(255) NOT NULL,
+    phone VARCHAR(17) NOT NULL,
+    street TEXT NOT NULL,
+    province VARCHAR(100) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    district VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(10) NOT NULL,
+    notes TEXT NULL,
+    latitude DOUBLE PRECISION NULL,
+    longitude DOUBLE PRECISION NULL,
+    is_default boolean DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL,
+    deleted_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_user_addresses_user_id ON user_addresses(user_id);
+CREATE UNIQUE INDEX idx_user_addresses_default ON user_addresses(user_id) WHERE is_default AND deleted_at IS NULL;
+
+-- migrate:down
+DROP TABLE IF EXISTS "user_addresses";
@@ -0,0 +1,40 @@
+-- migrate:up
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+-- Values that are not numbers or are out of range cannot be placed on a map
+-- and are dropped.
+ALTER TABLE users
+    ALTER COLUMN lat TYPE DOUBLE PRECISION USING (
+        CASE WHEN lat ~ '^\s*-?[0-9]+(\.[0-9]+)?\s*$' THEN
+            CASE WHEN lat::DOUBLE PRECISION BETWEEN -90 AND 90 THEN lat::DOUBLE PRECISION END
+        END
+    ),
+    ALTER COLUMN lng TYPE DOUBLE PRECISION USING (
+        CASE WHEN lng ~ '^\s*-?[0-9]+(\.[0-9]+)?\s*$' THEN
+            CASE WHEN lng::DOUBLE PRECISION BETWEEN -180 AND 180 THEN lng::DOUBLE PRECISION END
+        END
+    );
+
+-- Profile updates used to store 0,0 when no location was sent.
+UPDATE users SET lat = NULL, lng = NULL WHERE (lat = 0 AND lng = 0) OR lat IS NULL OR lng IS NULL;
+
+ALTER TABLE users
+    ADD CONSTRAINT chk_users_lat CHECK (lat BETWEEN -90 AND 90),
+    ADD CONSTRAINT chk_users_lng CHECK (lng BETWEEN -180 AND 180),
+    ADD COLUMN location GEOGRAPHY(Point, 4326) GENERATED ALWAYS AS (
+        CASE WHEN lat IS NOT NULL AND lng IS NOT NULL THEN
+            ST_SetSRID(ST_MakePoint(lng, lat), 4326)::GEOGRAPHY
+        END
+    ) STORED;
+
+CREATE INDEX idx_users_location ON users USING GIST (location);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_users_location;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS location,
+    DROP CONSTRAINT IF EXISTS chk_users_lat,
+    DROP CONSTRAINT IF EXISTS chk_users_lng,
+    ALTER COLUMN lat TYPE VARCHAR(50) USING lat::TEXT,
+    ALTER COLUMN lng TYPE VARCHAR(50) USING lng::TEXT;
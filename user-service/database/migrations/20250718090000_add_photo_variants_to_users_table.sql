@@ -0,0 +1,11 @@
+-- migrate:up
+ALTER TABLE users
+    ADD COLUMN photo_medium VARCHAR(255) NULL,
+    ADD COLUMN photo_thumbnail VARCHAR(255) NULL,
+    ADD COLUMN photo_path VARCHAR(255) NULL;
+
+-- migrate:down
+ALTER TABLE users
+    DROP COLUMN IF EXISTS photo_medium,
+    DROP COLUMN IF EXISTS photo_thumbnail,
+    DROP COLUMN IF EXISTS photo_path;
@@ -0,0 +1,21 @@
+-- migrate:up
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    exchange VARCHAR(100) NOT NULL DEFAULT '',
+    routing_key VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The relay only looks at unpublished rows, oldest first per aggregate.
+CREATE INDEX idx_outbox_events_pending ON outbox_events(aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events(published_at) WHERE published_at IS NOT NULL;
+
+-- migrate:down
+DROP TABLE IF EXISTS "outbox_events";
@@ -0,0 +1,22 @@
+-- migrate:up
+-- Emails are matched case-insensitively, so addresses that differ only in
+-- case must not belong to two accounts. All but one of such accounts, active
+-- and verified ones first, then the oldest, get their email renamed so the
+-- index can be built; their owners can reclaim them through support.
+UPDATE users SET email = LEFT('duplicate-' || id || '-' || email, 255)
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (
+            PARTITION BY LOWER(email)
+            ORDER BY deleted_at IS NOT NULL, is_verified IS NOT TRUE, id
+        ) AS position
+        FROM users
+    ) ranked
+    WHERE position > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_lower_email ON users(LOWER(email));
+
+-- migrate:down
+-- Renamed duplicates are not restored.
+DROP INDEX IF EXISTS idx_users_lower_email;
@@ -4,12 +4,16 @@ import (
 	"github.com/labstack/gommon/log"
 	"gorm.io/gorm"
 
+	"golang.org/x/crypto/bcrypt"
+
 	"user-service/internal/core/domain/model"
-	"user-service/utils/conv"
+	"user-service/utils/password"
 )
 
 func SeedAdmin(db *gorm.DB) {
-	bytes, err := conv.HashPassword("admin123")
+	// Seeded with the default cost; the hash is upgraded to the configured
+	// algorithm on the first sign in.
+	bytes, err := password.NewBcryptHasher(bcrypt.DefaultCost).Hash("admin123")
 	if err != nil {
 		log.Fatalf("%s: %v", err.Error(), err)
 	}
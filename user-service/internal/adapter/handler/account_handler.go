@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/request"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/service"
+)
+
+type AccountHandlerInterface interface {
+	DeleteAccount(c echo.Context) error
+	ExportData(c echo.Context) error
+}
+
+type accountHandler struct {
+	accountService service.AccountServiceInterface
+}
+
+// DeleteAccount implements AccountHandlerInterface.
+func (a *accountHandler) DeleteAccount(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.DeleteAccountRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AccountHandler-1] DeleteAccount: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[AccountHandler-2] DeleteAccount: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[AccountHandler-3] DeleteAccount: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = a.accountService.DeleteAccount(ctx, jwtUserData.UserID, req.Password, jwtUserData.FamilyID); err != nil {
+		log.Errorf("[AccountHandler-4] DeleteAccount: %v", err)
+		switch err.Error() {
+		case "401":
+			resp.Message, resp.Data = "password is incorrect", nil
+			return c.JSON(http.StatusUnauthorized, resp)
+		case "403":
+			resp.Message, resp.Data = "please sign in again or enter your password", nil
+			return c.JSON(http.StatusForbidden, resp)
+		case "404":
+			resp.Message, resp.Data = "user not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		case "423":
+			resp.Message, resp.Data = "account temporarily locked, please try again later", nil
+			return c.JSON(http.StatusLocked, resp)
+		case "429":
+			resp.Message, resp.Data = "too many failed attempts, please wait before trying again", nil
+			return c.JSON(http.StatusTooManyRequests, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ExportData implements AccountHandlerInterface.
+func (a *accountHandler) ExportData(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AccountHandler-1] ExportData: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	export, err := a.accountService.ExportData(ctx, jwtUserData.UserID, jwtUserData.FamilyID)
+	if err != nil {
+		log.Errorf("[AccountHandler-2] ExportData: %v", err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "user not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"account-%d-%s.json\"", jwtUserData.UserID, export.ExportedAt.Format("20060102")))
+
+	resp.Message = "success"
+	resp.Data = accountExportResponse(export)
+	return c.JSON(http.StatusOK, resp)
+}
+
+func accountExportResponse(export *entity.AccountExportEntity) response.AccountExportResponse {
+	profile := export.Profile
+	respExport := response.AccountExportResponse{
+		ExportedAt: export.ExportedAt.Format(time.RFC3339),
+		Profile: response.AccountProfileResponse{
+			ID:               profile.ID,
+			Name:             profile.Name,
+			Email:            profile.Email,
+			PendingEmail:     profile.PendingEmail,
+			Phone:            profile.Phone,
+			PhoneVerified:    profile.PhoneVerified,
+			Address:          profile.Address,
+			Lat:              profile.Lat,
+			Lng:              profile.Lng,
+			Photo:            profile.Photo,
+			Roles:            profile.Roles,
+			IsVerified:       profile.IsVerified,
+			TwoFactorEnabled: profile.TwoFactorEnabled,
+			CreatedAt:        profile.CreatedAt.Format(time.RFC3339),
+		},
+		Identities: []response.AccountIdentityResponse{},
+		Tokens:     []response.AccountTokenResponse{},
+		Addresses:  []response.AddressResponse{},
+		Sessions:   []response.SessionResponse{},
+	}
+
+	for _, identity := range export.Identities {
+		respExport.Identities = append(respExport.Identities, response.AccountIdentityResponse{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	for _, token := range export.Tokens {
+		respToken := response.AccountTokenResponse{
+			Type:      token.TokenType,
+			CreatedAt: token.CreatedAt.Format(time.RFC3339),
+			ExpiresAt: token.ExpiresAt.Format(time.RFC3339),
+		}
+		if token.UsedAt != nil {
+			respToken.UsedAt = token.UsedAt.Format(time.RFC3339)
+		}
+		respExport.Tokens = append(respExport.Tokens, respToken)
+	}
+
+	for _, address := range export.Addresses {
+		respExport.Addresses = append(respExport.Addresses, addressResponse(address))
+	}
+
+	for _, session := range export.Sessions {
+		respExport.Sessions = append(respExport.Sessions, response.SessionResponse{
+			ID:         session.ID,
+			Device:     session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+			Current:    session.Current,
+		})
+	}
+
+	return respExport
+}
+
+func NewAccountHandler(e *echo.Echo, accountService service.AccountServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) AccountHandlerInterface {
+	accountHandler := &accountHandler{
+		accountService: accountService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+
+	authGroup := e.Group("/auth/account", mid.CheckToken(), mid.RateLimit("auth"))
+	authGroup.DELETE("", accountHandler.DeleteAccount)
+	authGroup.GET("/export", accountHandler.ExportData)
+
+	return accountHandler
+}
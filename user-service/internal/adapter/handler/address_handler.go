@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/request"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/service"
+	"user-service/utils/phone"
+)
+
+type AddressHandlerInterface interface {
+	GetAll(c echo.Context) error
+	GetByID(c echo.Context) error
+	Create(c echo.Context) error
+	Update(c echo.Context) error
+	Delete(c echo.Context) error
+	SetDefault(c echo.Context) error
+}
+
+type addressHandler struct {
+	addressService service.AddressServiceInterface
+}
+
+// GetAll implements AddressHandlerInterface.
+func (a *addressHandler) GetAll(c echo.Context) error {
+	var (
+		resp          = response.DefaultResponse{}
+		respAddresses = []response.AddressResponse{}
+		ctx           = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AddressHandler-1] GetAll: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	addresses, err := a.addressService.GetAll(ctx, jwtUserData.UserID)
+	if err != nil {
+		log.Errorf("[AddressHandler-2] GetAll: %v", err)
+		return addressErrorResponse(c, err)
+	}
+
+	for _, address := range addresses {
+		respAddresses = append(respAddresses, addressResponse(address))
+	}
+
+	resp.Message = "success"
+	resp.Data = respAddresses
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetByID implements AddressHandlerInterface.
+func (a *addressHandler) GetByID(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AddressHandler-1] GetByID: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	addressID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[AddressHandler-2] GetByID: %v", err)
+		resp.Message, resp.Data = "invalid address id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	address, err := a.addressService.GetByID(ctx, jwtUserData.UserID, addressID)
+	if err != nil {
+		log.Errorf("[AddressHandler-3] GetByID: %v", err)
+		return addressErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = addressResponse(*address)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Create implements AddressHandlerInterface.
+func (a *addressHandler) Create(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.AddressRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AddressHandler-1] Create: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[AddressHandler-2] Create: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[AddressHandler-3] Create: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	address, err := a.addressService.Create(ctx, addressEntity(jwtUserData.UserID, 0, req))
+	if err != nil {
+		log.Errorf("[AddressHandler-4] Create: %v", err)
+		return addressErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = addressResponse(*address)
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// Update implements AddressHandlerInterface.
+func (a *addressHandler) Update(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.AddressRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AddressHandler-1] Update: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	addressID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[AddressHandler-2] Update: %v", err)
+		resp.Message, resp.Data = "invalid address id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[AddressHandler-3] Update: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[AddressHandler-4] Update: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = a.addressService.Update(ctx, addressEntity(jwtUserData.UserID, addressID, req)); err != nil {
+		log.Errorf("[AddressHandler-5] Update: %v", err)
+		return addressErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Delete implements AddressHandlerInterface.
+func (a *addressHandler) Delete(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AddressHandler-1] Delete: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	addressID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[AddressHandler-2] Delete: %v", err)
+		resp.Message, resp.Data = "invalid address id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = a.addressService.Delete(ctx, jwtUserData.UserID, addressID); err != nil {
+		log.Errorf("[AddressHandler-3] Delete: %v", err)
+		return addressErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetDefault implements AddressHandlerInterface.
+func (a *addressHandler) SetDefault(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[AddressHandler-1] SetDefault: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	addressID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[AddressHandler-2] SetDefault: %v", err)
+		resp.Message, resp.Data = "invalid address id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = a.addressService.SetDefault(ctx, jwtUserData.UserID, addressID); err != nil {
+		log.Errorf("[AddressHandler-3] SetDefault: %v", err)
+		return addressErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// addressEntity maps a validated request, so the phone number is known to
+// normalize cleanly.
+func addressEntity(userID, id int64, req request.AddressRequest) entity.AddressEntity {
+	phoneStr, _ := phone.Normalize(req.Phone)
+
+	return entity.AddressEntity{
+		ID:            id,
+		UserID:        userID,
+		Label:         strings.TrimSpace(req.Label),
+		RecipientName: strings.TrimSpace(req.RecipientName),
+		Phone:         phoneStr,
+		Street:        strings.TrimSpace(req.Street),
+		Province:      strings.TrimSpace(req.Province),
+		City:          strings.TrimSpace(req.City),
+		District:      strings.TrimSpace(req.District),
+		PostalCode:    req.PostalCode,
+		Notes:         strings.TrimSpace(req.Notes),
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		IsDefault:     req.IsDefault,
+	}
+}
+
+func addressResponse(address entity.AddressEntity) response.AddressResponse {
+	respAddress := response.AddressResponse{
+		ID:            address.ID,
+		Label:         address.Label,
+		RecipientName: address.RecipientName,
+		Phone:         address.Phone,
+		Street:        address.Street,
+		Province:      address.Province,
+		City:          address.City,
+		District:      address.District,
+		PostalCode:    address.PostalCode,
+		Notes:         address.Notes,
+		Latitude:      address.Latitude,
+		Longitude:     address.Longitude,
+		IsDefault:     address.IsDefault,
+		CreatedAt:     address.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     address.UpdatedAt.Format(time.RFC3339),
+	}
+	if address.DeletedAt != nil {
+		respAddress.DeletedAt = address.DeletedAt.Format(time.RFC3339)
+	}
+
+	return respAddress
+}
+
+func addressErrorResponse(c echo.Context, err error) error {
+	resp := response.DefaultResponse{}
+
+	switch err.Error() {
+	case "404":
+		resp.Message = "data not found"
+		return c.JSON(http.StatusNotFound, resp)
+	case "422":
+		resp.Message = "address book is full"
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	resp.Message = err.Error()
+	return c.JSON(http.StatusInternalServerError, resp)
+}
+
+func NewAddressHandler(e *echo.Echo, addressService service.AddressServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) AddressHandlerInterface {
+	addressHandler := &addressHandler{
+		addressService: addressService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+
+	authGroup := e.Group("/auth/addresses", mid.CheckToken(), mid.RateLimit("auth"))
+	authGroup.GET("", addressHandler.GetAll)
+	authGroup.GET("/:id", addressHandler.GetByID)
+	authGroup.POST("", addressHandler.Create)
+	authGroup.PUT("/:id", addressHandler.Update)
+	authGroup.DELETE("/:id", addressHandler.Delete)
+	authGroup.PUT("/:id/default", addressHandler.SetDefault)
+
+	return addressHandler
+}
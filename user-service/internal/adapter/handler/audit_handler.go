@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/service"
+)
+
+type AuditHandlerInterface interface {
+	GetAll(c echo.Context) error
+}
+
+type auditHandler struct {
+	auditService service.AuditServiceInterface
+}
+
+// GetAll implements AuditHandlerInterface.
+// from and to accept RFC 3339 timestamps or dates (YYYY-MM-DD); to is
+// exclusive.
+func (a *auditHandler) GetAll(c echo.Context) error {
+	var (
+		resp     = response.DefaultResponseWithPaginations{}
+		respList = []response.AuditLogResponse{}
+		ctx      = c.Request().Context()
+	)
+
+	page, err := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+
+	query := entity.QueryAuditLogEntity{
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	for param, dest := range map[string]*int64{"actor_id": &query.ActorID, "target_id": &query.TargetID} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		if *dest, err = strconv.ParseInt(value, 10, 64); err != nil {
+			log.Errorf("[AuditHandler-1] GetAll: %v", err)
+			resp.Message, resp.Data = "invalid "+param+" value", nil
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+	}
+
+	for param, dest := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseAuditTime(value)
+		if err != nil {
+			log.Errorf("[AuditHandler-2] GetAll: %v", err)
+			resp.Message, resp.Data = "invalid "+param+" value", nil
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		*dest = &parsed
+	}
+
+	auditLogs, totalData, totalPage, err := a.auditService.GetAll(ctx, query)
+	if err != nil {
+		log.Errorf("[AuditHandler-3] GetAll: %v", err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "audit log not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	for _, val := range auditLogs {
+		respLog := response.AuditLogResponse{
+			ID:         val.ID,
+			Action:     val.Action,
+			ActorID:    val.ActorID,
+			TargetType: val.TargetType,
+			TargetID:   val.TargetID,
+			IPAddress:  val.IPAddress,
+			UserAgent:  val.UserAgent,
+			Metadata:   val.Metadata,
+			CreatedAt:  val.CreatedAt.Format(time.RFC3339),
+		}
+
+		if len(val.Changes) > 0 {
+			respLog.Changes = map[string]response.AuditChangeResponse{}
+			for field, change := range val.Changes {
+				respLog.Changes[field] = response.AuditChangeResponse{Old: change.Old, New: change.New}
+			}
+		}
+
+		respList = append(respList, respLog)
+	}
+
+	resp.Message = "success"
+	resp.Data = respList
+	resp.Pagination = &response.Pagination{
+		Page:       page,
+		TotalCount: totalData,
+		PerPage:    limit,
+		TotalPage:  totalPage,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func parseAuditTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func NewAuditHandler(e *echo.Echo, auditService service.AuditServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) AuditHandlerInterface {
+	auditHandler := &auditHandler{
+		auditService: auditService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+
+	adminGroup := e.Group("/admin", mid.CheckToken(), mid.RequireRole("Super Admin"), mid.RateLimit("admin"))
+	adminGroup.GET("/audit-logs", auditHandler.GetAll)
+
+	return auditHandler
+}
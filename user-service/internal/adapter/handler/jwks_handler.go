@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"user-service/internal/core/service"
+)
+
+type JwksHandlerInterface interface {
+	GetJWKS(c echo.Context) error
+}
+
+type jwksHandler struct {
+	jwtService service.JwtServiceInterface
+}
+
+// GetJWKS implements JwksHandlerInterface.
+// The key set is returned as-is instead of in the default response envelope
+// so standard JWT libraries in other services can consume it directly.
+func (j *jwksHandler) GetJWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, j.jwtService.GetJWKS())
+}
+
+func NewJwksHandler(e *echo.Echo, jwtService service.JwtServiceInterface) JwksHandlerInterface {
+	jwksHandler := &jwksHandler{
+		jwtService: jwtService,
+	}
+
+	e.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	return jwksHandler
+}
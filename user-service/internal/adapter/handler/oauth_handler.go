@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/service"
+)
+
+type OAuthHandlerInterface interface {
+	Login(c echo.Context) error
+	Callback(c echo.Context) error
+}
+
+type oauthHandler struct {
+	oauthService service.OAuthServiceInterface
+}
+
+// Login implements OAuthHandlerInterface.
+func (o *oauthHandler) Login(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	authURL, err := o.oauthService.AuthURL(ctx, c.Param("provider"))
+	if err != nil {
+		log.Errorf("[OAuthHandler-1] Login: %v", err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "unknown provider", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback implements OAuthHandlerInterface.
+func (o *oauthHandler) Callback(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	if errParam := c.QueryParam("error"); errParam != "" {
+		log.Errorf("[OAuthHandler-1] Callback: %s", errParam)
+		resp.Message, resp.Data = "authorization was denied by the provider", nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	code, state := c.QueryParam("code"), c.QueryParam("state")
+	if code == "" || state == "" {
+		resp.Message, resp.Data = "missing code or state", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	user, session, err := o.oauthService.Callback(ctx, c.Param("provider"), code, state, sessionClient(c))
+	if err != nil {
+		log.Errorf("[OAuthHandler-2] Callback: %v", err)
+		switch err.Error() {
+		case "401":
+			resp.Message, resp.Data = "sign in expired or invalid, please try again", nil
+			return c.JSON(http.StatusUnauthorized, resp)
+		case "403":
+			resp.Message, resp.Data = "account suspended or deleted", nil
+			return c.JSON(http.StatusForbidden, resp)
+		case "404":
+			resp.Message, resp.Data = "unknown provider", nil
+			return c.JSON(http.StatusNotFound, resp)
+		case "422":
+			resp.Message, resp.Data = "the provider did not return a verified email", nil
+			return c.JSON(http.StatusUnprocessableEntity, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	if session.TwoFactorChallenge != "" {
+		resp.Message = "two factor authentication required"
+		resp.Data = twoFactorChallengeResponse(session)
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	resp.Message = "success"
+	resp.Data = signInResponse(user, session)
+	return c.JSON(http.StatusOK, resp)
+}
+
+func NewOAuthHandler(e *echo.Echo, oauthService service.OAuthServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) OAuthHandlerInterface {
+	oauthHandler := &oauthHandler{
+		oauthService: oauthService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+
+	e.GET("/oauth/:provider/login", oauthHandler.Login, mid.RateLimit("public"))
+	e.GET("/oauth/:provider/callback", oauthHandler.Callback, mid.RateLimit("public"))
+
+	return oauthHandler
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/request"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/service"
+)
+
+type PasswordlessHandlerInterface interface {
+	RequestSignIn(c echo.Context) error
+	VerifyCode(c echo.Context) error
+	VerifyLink(c echo.Context) error
+}
+
+type passwordlessHandler struct {
+	passwordlessService service.PasswordlessServiceInterface
+}
+
+// RequestSignIn implements PasswordlessHandlerInterface.
+func (p *passwordlessHandler) RequestSignIn(c echo.Context) error {
+	var (
+		req  = request.PasswordlessRequest{}
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[PasswordlessHandler-1] RequestSignIn: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[PasswordlessHandler-2] RequestSignIn: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := p.passwordlessService.RequestSignIn(ctx, req.Email); err != nil {
+		log.Errorf("[PasswordlessHandler-3] RequestSignIn: %v", err)
+		switch err.Error() {
+		case "403":
+			resp.Message, resp.Data = "account suspended", nil
+			return c.JSON(http.StatusForbidden, resp)
+		case "404":
+			resp.Message, resp.Data = "no verified account with this email", nil
+			return c.JSON(http.StatusNotFound, resp)
+		case "429":
+			resp.Message, resp.Data = "a sign in code was sent recently, please check your email or try again later", nil
+			return c.JSON(http.StatusTooManyRequests, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// VerifyCode implements PasswordlessHandlerInterface.
+func (p *passwordlessHandler) VerifyCode(c echo.Context) error {
+	var (
+		req  = request.PasswordlessCodeRequest{}
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[PasswordlessHandler-1] VerifyCode: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[PasswordlessHandler-2] VerifyCode: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	user, session, err := p.passwordlessService.VerifyCode(ctx, req.Email, req.Code, sessionClient(c))
+	if err != nil {
+		log.Errorf("[PasswordlessHandler-3] VerifyCode: %v", err)
+		return passwordlessErrorResponse(c, err)
+	}
+
+	return passwordlessSignInResponse(c, user, session)
+}
+
+// VerifyLink implements PasswordlessHandlerInterface.
+func (p *passwordlessHandler) VerifyLink(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	token := c.QueryParam("token")
+	if token == "" {
+		resp.Message, resp.Data = "missing token", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	user, session, err := p.passwordlessService.VerifyLink(ctx, token, sessionClient(c))
+	if err != nil {
+		log.Errorf("[PasswordlessHandler-1] VerifyLink: %v", err)
+		return passwordlessErrorResponse(c, err)
+	}
+
+	return passwordlessSignInResponse(c, user, session)
+}
+
+func passwordlessSignInResponse(c echo.Context, user *entity.UserEntity, session *entity.SessionEntity) error {
+	resp := response.DefaultResponse{}
+
+	if session.TwoFactorChallenge != "" {
+		resp.Message = "two factor authentication required"
+		resp.Data = twoFactorChallengeResponse(session)
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	resp.Message = "success"
+	resp.Data = signInResponse(user, session)
+	return c.JSON(http.StatusOK, resp)
+}
+
+func passwordlessErrorResponse(c echo.Context, err error) error {
+	resp := response.DefaultResponse{}
+
+	switch err.Error() {
+	case "401", "404":
+		resp.Message = "invalid or expired sign in code"
+		return c.JSON(http.StatusUnauthorized, resp)
+	case "403":
+		resp.Message = "account suspended"
+		return c.JSON(http.StatusForbidden, resp)
+	case "429":
+		resp.Message = "too many attempts, please request a new sign in code"
+		return c.JSON(http.StatusTooManyRequests, resp)
+	}
+
+	resp.Message = err.Error()
+	return c.JSON(http.StatusInternalServerError, resp)
+}
+
+func NewPasswordlessHandler(e *echo.Echo, passwordlessService service.PasswordlessServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) PasswordlessHandlerInterface {
+	passwordlessHandler := &passwordlessHandler{
+		passwordlessService: passwordlessService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+
+	e.POST("/passwordless/request", passwordlessHandler.RequestSignIn, mid.RateLimit("email"))
+	e.POST("/passwordless/verify", passwordlessHandler.VerifyCode, mid.RateLimit("public"))
+	e.GET("/passwordless/verify", passwordlessHandler.VerifyLink, mid.RateLimit("public"))
+
+	return passwordlessHandler
+}
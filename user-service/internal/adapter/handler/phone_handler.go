@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/request"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/service"
+)
+
+type PhoneHandlerInterface interface {
+	SendCode(c echo.Context) error
+	VerifyCode(c echo.Context) error
+}
+
+type phoneHandler struct {
+	phoneVerificationService service.PhoneVerificationServiceInterface
+}
+
+// SendCode implements PhoneHandlerInterface.
+func (p *phoneHandler) SendCode(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[PhoneHandler-1] SendCode: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = p.phoneVerificationService.SendCode(ctx, jwtUserData.UserID); err != nil {
+		log.Errorf("[PhoneHandler-2] SendCode: %v", err)
+		return phoneErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// VerifyCode implements PhoneHandlerInterface.
+func (p *phoneHandler) VerifyCode(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.PhoneCodeRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[PhoneHandler-1] VerifyCode: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[PhoneHandler-2] VerifyCode: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[PhoneHandler-3] VerifyCode: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = p.phoneVerificationService.VerifyCode(ctx, jwtUserData.UserID, req.Code); err != nil {
+		log.Errorf("[PhoneHandler-4] VerifyCode: %v", err)
+		return phoneErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+func phoneErrorResponse(c echo.Context, err error) error {
+	resp := response.DefaultResponse{}
+
+	switch err.Error() {
+	case "401":
+		resp.Message = "invalid or expired verification code"
+		return c.JSON(http.StatusUnauthorized, resp)
+	case "404":
+		resp.Message = "user not found"
+		return c.JSON(http.StatusNotFound, resp)
+	case "409":
+		resp.Message = "phone number is already verified"
+		return c.JSON(http.StatusConflict, resp)
+	case "422":
+		resp.Message = "add a phone number to your profile first"
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	case "429":
+		resp.Message = "too many attempts, please try again later"
+		return c.JSON(http.StatusTooManyRequests, resp)
+	}
+
+	resp.Message = err.Error()
+	return c.JSON(http.StatusInternalServerError, resp)
+}
+
+func NewPhoneHandler(e *echo.Echo, phoneVerificationService service.PhoneVerificationServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) PhoneHandlerInterface {
+	phoneHandler := &phoneHandler{
+		phoneVerificationService: phoneVerificationService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+
+	authGroup := e.Group("/auth/phone", mid.CheckToken())
+	authGroup.POST("/send-code", phoneHandler.SendCode, mid.RateLimit("sms"))
+	authGroup.POST("/verify", phoneHandler.VerifyCode, mid.RateLimit("auth"))
+
+	return phoneHandler
+}
@@ -0,0 +1,7 @@
+package request
+
+// DeleteAccountRequest may leave out the password when the session has
+// just signed in, which is how accounts created with OAuth confirm it.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,16 @@
+package request
+
+type AddressRequest struct {
+	Label         string   `json:"label" validate:"required,max=50"`
+	RecipientName string   `json:"recipient_name" validate:"required,max=255"`
+	Phone         string   `json:"phone" validate:"required,phone"`
+	Street        string   `json:"street" validate:"required"`
+	Province      string   `json:"province" validate:"required,max=100"`
+	City          string   `json:"city" validate:"required,max=100"`
+	District      string   `json:"district" validate:"required,max=100"`
+	PostalCode    string   `json:"postal_code" validate:"required,numeric,max=10"`
+	Notes         string   `json:"notes" validate:"max=500"`
+	Latitude      *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude     *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	IsDefault     bool     `json:"is_default"`
+}
@@ -0,0 +1,10 @@
+package request
+
+type PasswordlessRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PasswordlessCodeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
@@ -0,0 +1,5 @@
+package request
+
+type PhoneCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
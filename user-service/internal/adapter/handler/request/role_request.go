@@ -0,0 +1,13 @@
+package request
+
+type RoleRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type AssignRoleRequest struct {
+	RoleID int64 `json:"role_id" validate:"required,gt=0"`
+}
+
+type RoleTwoFactorRequest struct {
+	Required bool `json:"required"`
+}
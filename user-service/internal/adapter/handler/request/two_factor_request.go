@@ -0,0 +1,14 @@
+package request
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorSetupRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
@@ -5,11 +5,15 @@ type SignInRequest struct {
 	Password string `json:"password" validate:"required,min=8"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 type SignUpRequest struct {
 	Name                 string `json:"name" validate:"required"`
 	Email                string `json:"email" validate:"required,email"`
-	Password             string `json:"password" validate:"required,min=8"`
-	PasswordConfirmation string `json:"password_confirmation" validate:"required,min=8"`
+	Password             string `json:"password" validate:"required,password"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
 }
 
 type ForgotPasswordRequest struct {
@@ -18,16 +22,19 @@ type ForgotPasswordRequest struct {
 
 type UpdatePasswordRequest struct {
 	CurrentPassword string `json:"password,omitempty"`
-	NewPassword     string `json:"password_new" validate:"required,min=8"`
-	ConfirmPassword string `json:"password_confirmation" validate:"required,min=8"`
+	NewPassword     string `json:"password_new" validate:"required,password"`
+	ConfirmPassword string `json:"password_confirmation" validate:"required,eqfield=NewPassword"`
 }
 
 type UpdateDataUserRequest struct {
-	Name    string  `json:"name" validate:"required"`
-	Email   string  `json:"email" validate:"required,email"`
-	Phone   int     `json:"phone"`
-	Address string  `json:"address"`
-	Lat     float64 `json:"lat"`
-	Lng     float64 `json:"lng"`
-	Photo   string  `json:"photo"`
+	Name    string   `json:"name" validate:"required"`
+	Email   string   `json:"email" validate:"required,email"`
+	Phone   string   `json:"phone" validate:"omitempty,phone"`
+	Address string   `json:"address"`
+	Lat     *float64 `json:"lat" validate:"required_with=Lng,omitempty,latitude"`
+	Lng     *float64 `json:"lng" validate:"required_with=Lat,omitempty,longitude"`
+}
+
+type PhotoUploadRequest struct {
+	ContentType string `json:"content_type" validate:"required"`
 }
@@ -0,0 +1,40 @@
+package response
+
+type AccountExportResponse struct {
+	ExportedAt string                    `json:"exported_at"`
+	Profile    AccountProfileResponse    `json:"profile"`
+	Identities []AccountIdentityResponse `json:"identities"`
+	Tokens     []AccountTokenResponse    `json:"tokens"`
+	Addresses  []AddressResponse         `json:"addresses"`
+	Sessions   []SessionResponse         `json:"sessions"`
+}
+
+type AccountProfileResponse struct {
+	ID               int64    `json:"id"`
+	Name             string   `json:"name"`
+	Email            string   `json:"email"`
+	PendingEmail     string   `json:"pending_email,omitempty"`
+	Phone            string   `json:"phone"`
+	PhoneVerified    bool     `json:"phone_verified"`
+	Address          string   `json:"address"`
+	Lat              *float64 `json:"lat"`
+	Lng              *float64 `json:"lng"`
+	Photo            string   `json:"photo"`
+	Roles            []string `json:"roles"`
+	IsVerified       bool     `json:"is_verified"`
+	TwoFactorEnabled bool     `json:"two_factor_enabled"`
+	CreatedAt        string   `json:"created_at"`
+}
+
+type AccountIdentityResponse struct {
+	Provider  string `json:"provider"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+type AccountTokenResponse struct {
+	Type      string `json:"type"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+	UsedAt    string `json:"used_at,omitempty"`
+}
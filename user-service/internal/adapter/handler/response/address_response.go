@@ -0,0 +1,20 @@
+package response
+
+type AddressResponse struct {
+	ID            int64    `json:"id"`
+	Label         string   `json:"label"`
+	RecipientName string   `json:"recipient_name"`
+	Phone         string   `json:"phone"`
+	Street        string   `json:"street"`
+	Province      string   `json:"province"`
+	City          string   `json:"city"`
+	District      string   `json:"district"`
+	PostalCode    string   `json:"postal_code"`
+	Notes         string   `json:"notes"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+	IsDefault     bool     `json:"is_default"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+	DeletedAt     string   `json:"deleted_at,omitempty"`
+}
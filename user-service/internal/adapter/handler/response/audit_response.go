@@ -0,0 +1,19 @@
+package response
+
+type AuditLogResponse struct {
+	ID         int64                          `json:"id"`
+	Action     string                         `json:"action"`
+	ActorID    int64                          `json:"actor_id,omitempty"`
+	TargetType string                         `json:"target_type,omitempty"`
+	TargetID   int64                          `json:"target_id,omitempty"`
+	IPAddress  string                         `json:"ip_address"`
+	UserAgent  string                         `json:"user_agent"`
+	Changes    map[string]AuditChangeResponse `json:"changes,omitempty"`
+	Metadata   map[string]interface{}         `json:"metadata,omitempty"`
+	CreatedAt  string                         `json:"created_at"`
+}
+
+type AuditChangeResponse struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
@@ -0,0 +1,7 @@
+package response
+
+type RoleResponse struct {
+	ID               int64  `json:"id"`
+	Name             string `json:"name"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
+}
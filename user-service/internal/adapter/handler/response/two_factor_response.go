@@ -0,0 +1,21 @@
+package response
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	SetupRequired     bool   `json:"setup_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorSignInResponse struct {
+	SignInResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -1,24 +1,103 @@
 package response
 
 type SignInResponse struct {
-	AccessToken string `json:"access_token"`
-	Role        string `json:"role"`
+	AccessToken  string   `json:"access_token"`
+	RefreshToken string   `json:"refresh_token"`
+	ExpiresIn    int64    `json:"expires_in"`
+	Role         string   `json:"role"`
+	ID           int64    `json:"id"`
+	Name         string   `json:"name"`
+	Email        string   `json:"email"`
+	Phone        string   `json:"phone"`
+	Lat          *float64 `json:"lat"`
+	Lng          *float64 `json:"lng"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type ProfileResponse struct {
+	RoleName       string   `json:"role"`
+	ID             int64    `json:"id"`
+	Name           string   `json:"name"`
+	Email          string   `json:"email"`
+	PendingEmail   string   `json:"pending_email,omitempty"`
+	Phone          string   `json:"phone"`
+	PhoneVerified  bool     `json:"phone_verified"`
+	Lat            *float64 `json:"lat"`
+	Lng            *float64 `json:"lng"`
+	Address        string   `json:"address"`
+	Photo          string   `json:"photo"`
+	PhotoMedium    string   `json:"photo_medium"`
+	PhotoThumbnail string   `json:"photo_thumbnail"`
+}
+
+type ProfilePhotoResponse struct {
+	ImageURL     string `json:"image_url"`
+	MediumURL    string `json:"medium_url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// PhotoUploadResponse describes how to upload straight to storage. For
+// POST, send a multipart form with every field followed by the photo in a
+// part named "file". For PUT, send the photo as the body with the headers.
+type PhotoUploadResponse struct {
+	UploadID  string            `json:"upload_id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	MaxSize   int64             `json:"max_size"`
+	ExpiresAt string            `json:"expires_at"`
+}
+
+type UserListResponse struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	Email       string `json:"email"`
 	Phone       string `json:"phone"`
-	Lat         string `json:"lat"`
-	Lng         string `json:"lng"`
+	Photo       string `json:"photo"`
+	RoleName    string `json:"role"`
+	IsVerified  bool   `json:"is_verified"`
+	IsSuspended bool   `json:"is_suspended"`
+	CreatedAt   string `json:"created_at"`
 }
 
-type ProfileResponse struct {
-	RoleName string `json:"role"`
-	ID       int64  `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Phone    string `json:"phone"`
-	Lat      string `json:"lat"`
-	Lng      string `json:"lng"`
-	Address  string `json:"address"`
-	Photo    string `json:"photo"`
+type UserDetailResponse struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Email       string   `json:"email"`
+	Phone       string   `json:"phone"`
+	Photo       string   `json:"photo"`
+	Address     string   `json:"address"`
+	Lat         *float64 `json:"lat"`
+	Lng         *float64 `json:"lng"`
+	RoleName    string   `json:"role"`
+	IsVerified  bool     `json:"is_verified"`
+	IsSuspended bool     `json:"is_suspended"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+type NearbyUserResponse struct {
+	ID             int64    `json:"id"`
+	Name           string   `json:"name"`
+	Email          string   `json:"email"`
+	Phone          string   `json:"phone"`
+	Address        string   `json:"address"`
+	Lat            *float64 `json:"lat"`
+	Lng            *float64 `json:"lng"`
+	RoleName       string   `json:"role"`
+	DistanceMeters float64  `json:"distance_meters"`
+}
+
+type SessionResponse struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	IPAddress  string `json:"ip_address"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	Current    bool   `json:"current"`
 }
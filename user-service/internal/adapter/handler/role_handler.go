@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/request"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/service"
+)
+
+type RoleHandlerInterface interface {
+	GetAll(c echo.Context) error
+	GetByID(c echo.Context) error
+	Create(c echo.Context) error
+	Update(c echo.Context) error
+	Delete(c echo.Context) error
+	AssignRoleToUser(c echo.Context) error
+	UnassignRoleFromUser(c echo.Context) error
+	UpdateTwoFactorRequirement(c echo.Context) error
+}
+
+type roleHandler struct {
+	roleService service.RoleServiceInterface
+}
+
+// GetAll implements RoleHandlerInterface.
+func (r *roleHandler) GetAll(c echo.Context) error {
+	var (
+		resp      = response.DefaultResponse{}
+		respRoles = []response.RoleResponse{}
+		ctx       = c.Request().Context()
+	)
+
+	search := c.QueryParam("search")
+	roles, err := r.roleService.GetAll(ctx, search)
+	if err != nil {
+		log.Errorf("[RoleHandler-1] GetAll: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	for _, role := range roles {
+		respRoles = append(respRoles, response.RoleResponse{
+			ID:               role.ID,
+			Name:             role.Name,
+			RequireTwoFactor: role.RequireTwoFactor,
+		})
+	}
+
+	resp.Message = "success"
+	resp.Data = respRoles
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetByID implements RoleHandlerInterface.
+func (r *roleHandler) GetByID(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	roleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[RoleHandler-1] GetByID: %v", err)
+		resp.Message, resp.Data = "invalid role id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	role, err := r.roleService.GetByID(ctx, roleID)
+	if err != nil {
+		log.Errorf("[RoleHandler-2] GetByID: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = response.RoleResponse{
+		ID:               role.ID,
+		Name:             role.Name,
+		RequireTwoFactor: role.RequireTwoFactor,
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Create implements RoleHandlerInterface.
+func (r *roleHandler) Create(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.RoleRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[RoleHandler-1] Create: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[RoleHandler-2] Create: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	err := r.roleService.Create(ctx, entity.RoleEntity{Name: strings.TrimSpace(req.Name)})
+	if err != nil {
+		log.Errorf("[RoleHandler-3] Create: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// Update implements RoleHandlerInterface.
+func (r *roleHandler) Update(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.RoleRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	roleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[RoleHandler-1] Update: %v", err)
+		resp.Message, resp.Data = "invalid role id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[RoleHandler-2] Update: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[RoleHandler-3] Update: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	err = r.roleService.Update(ctx, entity.RoleEntity{ID: roleID, Name: strings.TrimSpace(req.Name)})
+	if err != nil {
+		log.Errorf("[RoleHandler-4] Update: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Delete implements RoleHandlerInterface.
+func (r *roleHandler) Delete(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	roleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[RoleHandler-1] Delete: %v", err)
+		resp.Message, resp.Data = "invalid role id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = r.roleService.Delete(ctx, roleID); err != nil {
+		log.Errorf("[RoleHandler-2] Delete: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// AssignRoleToUser implements RoleHandlerInterface.
+func (r *roleHandler) AssignRoleToUser(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.AssignRoleRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[RoleHandler-1] AssignRoleToUser: %v", err)
+		resp.Message, resp.Data = "invalid user id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[RoleHandler-2] AssignRoleToUser: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[RoleHandler-3] AssignRoleToUser: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = r.roleService.AssignRoleToUser(ctx, userID, req.RoleID); err != nil {
+		log.Errorf("[RoleHandler-4] AssignRoleToUser: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UnassignRoleFromUser implements RoleHandlerInterface.
+func (r *roleHandler) UnassignRoleFromUser(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[RoleHandler-1] UnassignRoleFromUser: %v", err)
+		resp.Message, resp.Data = "invalid user id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	roleID, err := strconv.ParseInt(c.Param("roleId"), 10, 64)
+	if err != nil {
+		log.Errorf("[RoleHandler-2] UnassignRoleFromUser: %v", err)
+		resp.Message, resp.Data = "invalid role id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = r.roleService.UnassignRoleFromUser(ctx, userID, roleID); err != nil {
+		log.Errorf("[RoleHandler-3] UnassignRoleFromUser: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UpdateTwoFactorRequirement implements RoleHandlerInterface.
+func (r *roleHandler) UpdateTwoFactorRequirement(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.RoleTwoFactorRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	roleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[RoleHandler-1] UpdateTwoFactorRequirement: %v", err)
+		resp.Message, resp.Data = "invalid role id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[RoleHandler-2] UpdateTwoFactorRequirement: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = r.roleService.UpdateTwoFactorRequirement(ctx, roleID, req.Required); err != nil {
+		log.Errorf("[RoleHandler-3] UpdateTwoFactorRequirement: %v", err)
+		return roleErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+func roleErrorResponse(c echo.Context, err error) error {
+	resp := response.DefaultResponse{}
+
+	switch err.Error() {
+	case "404":
+		resp.Message = "data not found"
+		return c.JSON(http.StatusNotFound, resp)
+	case "403":
+		resp.Message = "this role cannot be modified"
+		return c.JSON(http.StatusForbidden, resp)
+	case "409":
+		resp.Message = "role already exists or is still in use"
+		return c.JSON(http.StatusConflict, resp)
+	case "422":
+		resp.Message = "user must have at least one role"
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	resp.Message = err.Error()
+	return c.JSON(http.StatusInternalServerError, resp)
+}
+
+func NewRoleHandler(e *echo.Echo, roleService service.RoleServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) RoleHandlerInterface {
+	roleHandler := &roleHandler{
+		roleService: roleService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+	adminGroup := e.Group("/admin", mid.CheckToken(), mid.RequireRole("Super Admin"), mid.RateLimit("admin"))
+
+	adminGroup.GET("/roles", roleHandler.GetAll)
+	adminGroup.GET("/roles/:id", roleHandler.GetByID)
+	adminGroup.POST("/roles", roleHandler.Create)
+	adminGroup.PUT("/roles/:id", roleHandler.Update)
+	adminGroup.DELETE("/roles/:id", roleHandler.Delete)
+	adminGroup.PUT("/roles/:id/two-factor", roleHandler.UpdateTwoFactorRequirement)
+
+	adminGroup.POST("/users/:id/roles", roleHandler.AssignRoleToUser)
+	adminGroup.DELETE("/users/:id/roles/:roleId", roleHandler.UnassignRoleFromUser)
+
+	return roleHandler
+}
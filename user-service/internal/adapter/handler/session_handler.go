@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/service"
+)
+
+type SessionHandlerInterface interface {
+	Logout(c echo.Context) error
+	GetSessions(c echo.Context) error
+	DeleteSession(c echo.Context) error
+	DeleteAllSessions(c echo.Context) error
+}
+
+type sessionHandler struct {
+	sessionService service.SessionServiceInterface
+}
+
+// Logout implements SessionHandlerInterface.
+func (s *sessionHandler) Logout(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[SessionHandler-1] Logout: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	// 401 means the session family is already gone, which is a logout too.
+	err = s.sessionService.RevokeFamily(ctx, jwtUserData.FamilyID)
+	if err != nil && err.Error() != "401" {
+		log.Errorf("[SessionHandler-2] Logout: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetSessions implements SessionHandlerInterface.
+func (s *sessionHandler) GetSessions(c echo.Context) error {
+	var (
+		resp         = response.DefaultResponse{}
+		respSessions = []response.SessionResponse{}
+		ctx          = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[SessionHandler-1] GetSessions: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	sessions, err := s.sessionService.ListUserSessions(ctx, jwtUserData.UserID, jwtUserData.FamilyID)
+	if err != nil {
+		log.Errorf("[SessionHandler-2] GetSessions: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	for _, session := range sessions {
+		respSessions = append(respSessions, response.SessionResponse{
+			ID:         session.ID,
+			Device:     session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+			Current:    session.Current,
+		})
+	}
+
+	resp.Message = "success"
+	resp.Data = respSessions
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteSession implements SessionHandlerInterface.
+func (s *sessionHandler) DeleteSession(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[SessionHandler-1] DeleteSession: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = s.sessionService.RevokeUserSession(ctx, jwtUserData.UserID, c.Param("id")); err != nil {
+		log.Errorf("[SessionHandler-2] DeleteSession: %v", err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "session not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteAllSessions implements SessionHandlerInterface.
+// It signs the user out everywhere, including the current session.
+func (s *sessionHandler) DeleteAllSessions(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[SessionHandler-1] DeleteAllSessions: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = s.sessionService.RevokeAllUserSessions(ctx, jwtUserData.UserID, ""); err != nil {
+		log.Errorf("[SessionHandler-2] DeleteAllSessions: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// getJwtUserData reads the session stored by the CheckToken middleware.
+func getJwtUserData(c echo.Context) (entity.JwtUserData, error) {
+	jwtUserData := entity.JwtUserData{}
+
+	user, ok := c.Get("user").(string)
+	if !ok || user == "" {
+		return jwtUserData, errors.New("data token not found")
+	}
+
+	if err := json.Unmarshal([]byte(user), &jwtUserData); err != nil {
+		return jwtUserData, err
+	}
+
+	return jwtUserData, nil
+}
+
+func sessionClient(c echo.Context) entity.SessionClientEntity {
+	return entity.SessionClientEntity{
+		UserAgent: c.Request().UserAgent(),
+		IPAddress: c.RealIP(),
+	}
+}
+
+func NewSessionHandler(e *echo.Echo, sessionService service.SessionServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) SessionHandlerInterface {
+	sessionHandler := &sessionHandler{
+		sessionService: sessionService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+	authGroup := e.Group("/auth", mid.CheckToken(), mid.RateLimit("auth"))
+
+	authGroup.POST("/logout", sessionHandler.Logout)
+	authGroup.GET("/sessions", sessionHandler.GetSessions)
+	authGroup.DELETE("/sessions", sessionHandler.DeleteAllSessions)
+	authGroup.DELETE("/sessions/:id", sessionHandler.DeleteSession)
+
+	return sessionHandler
+}
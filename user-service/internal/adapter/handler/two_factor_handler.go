@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/request"
+	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/service"
+)
+
+type TwoFactorHandlerInterface interface {
+	VerifyChallenge(c echo.Context) error
+	SetupWithChallenge(c echo.Context) error
+	ConfirmWithChallenge(c echo.Context) error
+
+	Enroll(c echo.Context) error
+	Confirm(c echo.Context) error
+	Disable(c echo.Context) error
+}
+
+type twoFactorHandler struct {
+	twoFactorService service.TwoFactorServiceInterface
+}
+
+// VerifyChallenge implements TwoFactorHandlerInterface.
+func (t *twoFactorHandler) VerifyChallenge(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.TwoFactorChallengeRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[TwoFactorHandler-1] VerifyChallenge: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[TwoFactorHandler-2] VerifyChallenge: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	user, session, err := t.twoFactorService.VerifyChallenge(ctx, req.ChallengeToken, req.Code, sessionClient(c))
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-3] VerifyChallenge: %v", err)
+		if err.Error() == "403" {
+			resp.Message, resp.Data = "account suspended", nil
+			return c.JSON(http.StatusForbidden, resp)
+		}
+		return twoFactorErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = signInResponse(user, session)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetupWithChallenge implements TwoFactorHandlerInterface.
+func (t *twoFactorHandler) SetupWithChallenge(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.TwoFactorSetupRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[TwoFactorHandler-1] SetupWithChallenge: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[TwoFactorHandler-2] SetupWithChallenge: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	enrollment, err := t.twoFactorService.EnrollWithChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-3] SetupWithChallenge: %v", err)
+		return twoFactorErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = response.TwoFactorEnrollResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ConfirmWithChallenge implements TwoFactorHandlerInterface.
+func (t *twoFactorHandler) ConfirmWithChallenge(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.TwoFactorChallengeRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[TwoFactorHandler-1] ConfirmWithChallenge: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[TwoFactorHandler-2] ConfirmWithChallenge: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	recoveryCodes, user, session, err := t.twoFactorService.ConfirmWithChallenge(ctx, req.ChallengeToken, req.Code, sessionClient(c))
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-3] ConfirmWithChallenge: %v", err)
+		if err.Error() == "403" {
+			resp.Message, resp.Data = "account suspended", nil
+			return c.JSON(http.StatusForbidden, resp)
+		}
+		return twoFactorErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = response.TwoFactorSignInResponse{
+		SignInResponse: signInResponse(user, session),
+		RecoveryCodes:  recoveryCodes,
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Enroll implements TwoFactorHandlerInterface.
+func (t *twoFactorHandler) Enroll(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-1] Enroll: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	enrollment, err := t.twoFactorService.Enroll(ctx, jwtUserData.UserID)
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-2] Enroll: %v", err)
+		return twoFactorErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = response.TwoFactorEnrollResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Confirm implements TwoFactorHandlerInterface.
+func (t *twoFactorHandler) Confirm(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.TwoFactorCodeRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-1] Confirm: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[TwoFactorHandler-2] Confirm: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[TwoFactorHandler-3] Confirm: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	recoveryCodes, err := t.twoFactorService.Confirm(ctx, jwtUserData.UserID, req.Code)
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-4] Confirm: %v", err)
+		return twoFactorErrorResponse(c, err)
+	}
+
+	resp.Message = "success"
+	resp.Data = response.TwoFactorRecoveryCodesResponse{RecoveryCodes: recoveryCodes}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Disable implements TwoFactorHandlerInterface.
+func (t *twoFactorHandler) Disable(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.TwoFactorCodeRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[TwoFactorHandler-1] Disable: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[TwoFactorHandler-2] Disable: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[TwoFactorHandler-3] Disable: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = t.twoFactorService.Disable(ctx, jwtUserData.UserID, req.Code); err != nil {
+		log.Errorf("[TwoFactorHandler-4] Disable: %v", err)
+		return twoFactorErrorResponse(c, err)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+func twoFactorErrorResponse(c echo.Context, err error) error {
+	resp := response.DefaultResponse{}
+
+	switch err.Error() {
+	case "401":
+		resp.Message = "invalid code or challenge expired"
+		return c.JSON(http.StatusUnauthorized, resp)
+	case "403":
+		resp.Message = "two factor authentication is required for your role"
+		return c.JSON(http.StatusForbidden, resp)
+	case "404":
+		resp.Message = "two factor authentication is not set up"
+		return c.JSON(http.StatusNotFound, resp)
+	case "409":
+		resp.Message = "two factor authentication is already enabled"
+		return c.JSON(http.StatusConflict, resp)
+	case "423":
+		resp.Message = "too many invalid codes, please try again later"
+		return c.JSON(http.StatusLocked, resp)
+	case "428":
+		resp.Message = "two factor setup required"
+		return c.JSON(http.StatusPreconditionRequired, resp)
+	}
+
+	resp.Message = err.Error()
+	return c.JSON(http.StatusInternalServerError, resp)
+}
+
+func twoFactorChallengeResponse(session *entity.SessionEntity) response.TwoFactorChallengeResponse {
+	return response.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		SetupRequired:     session.TwoFactorSetup,
+		ChallengeToken:    session.TwoFactorChallenge,
+	}
+}
+
+func signInResponse(user *entity.UserEntity, session *entity.SessionEntity) response.SignInResponse {
+	return response.SignInResponse{
+		ID:           user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		Role:         user.RoleName,
+		Phone:        user.Phone,
+		Lat:          user.Lat,
+		Lng:          user.Lng,
+		AccessToken:  session.AccessToken,
+		RefreshToken: session.RefreshToken,
+		ExpiresIn:    session.ExpiresIn,
+	}
+}
+
+func NewTwoFactorHandler(e *echo.Echo, twoFactorService service.TwoFactorServiceInterface, cfg *config.Config, jwtService service.JwtServiceInterface) TwoFactorHandlerInterface {
+	twoFactorHandler := &twoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+
+	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
+
+	e.POST("/2fa/verify", twoFactorHandler.VerifyChallenge, mid.RateLimit("public"))
+	e.POST("/2fa/setup", twoFactorHandler.SetupWithChallenge, mid.RateLimit("public"))
+	e.POST("/2fa/setup/confirm", twoFactorHandler.ConfirmWithChallenge, mid.RateLimit("public"))
+
+	authGroup := e.Group("/auth/2fa", mid.CheckToken(), mid.RateLimit("auth"))
+	authGroup.POST("/enroll", twoFactorHandler.Enroll)
+	authGroup.POST("/confirm", twoFactorHandler.Confirm)
+	authGroup.POST("/disable", twoFactorHandler.Disable)
+
+	return twoFactorHandler
+}
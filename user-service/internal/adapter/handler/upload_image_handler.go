@@ -1,15 +1,13 @@
 package handler
 
 import (
-	"bytes"
-	"fmt"
 	"io"
 	"net/http"
 	"time"
 	"user-service/config"
 	"user-service/internal/adapter"
+	"user-service/internal/adapter/handler/request"
 	"user-service/internal/adapter/handler/response"
-	"user-service/internal/adapter/storage"
 	"user-service/internal/core/service"
 
 	"github.com/google/uuid"
@@ -19,38 +17,64 @@ import (
 
 type UploadImageI interface {
 	UploadImage(c echo.Context) error
+	RequestPhotoUpload(c echo.Context) error
+	CompletePhotoUpload(c echo.Context) error
 }
 
 type uploadImage struct {
-	storageHandler storage.SupabaseI
+	cfg                 *config.Config
+	profilePhotoService service.ProfilePhotoServiceInterface
 }
 
-func NewUploadImage(e *echo.Echo, cfg *config.Config, storageHandler storage.SupabaseI, jwtService service.JwtServiceInterface) UploadImageI {
+func NewUploadImage(e *echo.Echo, cfg *config.Config, profilePhotoService service.ProfilePhotoServiceInterface, jwtService service.JwtServiceInterface) UploadImageI {
 	res := &uploadImage{
-		storageHandler: storageHandler,
+		cfg:                 cfg,
+		profilePhotoService: profilePhotoService,
 	}
 
 	mid := adapter.NewMiddlewareAdapter(cfg, jwtService)
 
-	e.POST("/auth/profile/image-upload", res.UploadImage, mid.CheckToken())
+	e.POST("/auth/profile/image-upload", res.UploadImage, mid.CheckToken(), mid.RateLimit("auth"))
+
+	photoUploadApp := e.Group("/auth/profile/photo-upload", mid.CheckToken(), mid.RateLimit("auth"))
+	photoUploadApp.POST("", res.RequestPhotoUpload)
+	photoUploadApp.POST("/:id/complete", res.CompletePhotoUpload)
 
 	return res
 }
 
 func (u *uploadImage) UploadImage(c echo.Context) error {
-	var resp = response.DefaultResponse{}
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
 
-	file, err := c.FormFile("photo")
+	jwtUserData, err := getJwtUserData(c)
 	if err != nil {
 		log.Errorf("[uploadImage-1] UploadImage: %v", err)
 		resp.Message = err.Error()
 		resp.Data = nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		log.Errorf("[uploadImage-2] UploadImage: %v", err)
+		resp.Message = err.Error()
+		resp.Data = nil
 		return c.JSON(http.StatusUnprocessableEntity, resp)
 	}
 
+	if file.Size > u.cfg.Image.MaxUploadSize {
+		log.Infof("[uploadImage-3] UploadImage: file of %d bytes rejected", file.Size)
+		resp.Message = "photo is too large"
+		resp.Data = nil
+		return c.JSON(http.StatusRequestEntityTooLarge, resp)
+	}
+
 	src, err := file.Open()
 	if err != nil {
-		log.Errorf("[uploadImage-2] UploadImage: %v", err)
+		log.Errorf("[uploadImage-4] UploadImage: %v", err)
 		resp.Message = err.Error()
 		resp.Data = nil
 		return c.JSON(http.StatusBadRequest, resp)
@@ -58,36 +82,154 @@ func (u *uploadImage) UploadImage(c echo.Context) error {
 
 	defer src.Close()
 
-	fileBuffer := new(bytes.Buffer)
-	_, err = io.Copy(fileBuffer, src)
+	data, err := io.ReadAll(io.LimitReader(src, u.cfg.Image.MaxUploadSize))
 	if err != nil {
-		log.Errorf("[uploadImage-3] UploadImage: %v", err)
+		log.Errorf("[uploadImage-5] UploadImage: %v", err)
 		resp.Message = err.Error()
 		resp.Data = nil
 		return c.JSON(http.StatusBadRequest, resp)
 	}
 
-	newFileName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), getExtension(file.Filename))
+	photo, err := u.profilePhotoService.Upload(ctx, jwtUserData.UserID, data)
+	if err != nil {
+		log.Errorf("[uploadImage-6] UploadImage: %v", err)
+		resp.Data = nil
+		switch err.Error() {
+		case "404":
+			resp.Message = "user not found"
+			return c.JSON(http.StatusNotFound, resp)
+		case "413":
+			resp.Message = "photo dimensions are too large"
+			return c.JSON(http.StatusRequestEntityTooLarge, resp)
+		case "415":
+			resp.Message = "photo must be a JPEG, PNG or WebP image"
+			return c.JSON(http.StatusUnsupportedMediaType, resp)
+		case "422":
+			resp.Message = "photo could not be read"
+			return c.JSON(http.StatusUnprocessableEntity, resp)
+		}
+		resp.Message = err.Error()
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message = "Success"
+	resp.Data = response.ProfilePhotoResponse{
+		ImageURL:     photo.URL,
+		MediumURL:    photo.MediumURL,
+		ThumbnailURL: photo.ThumbnailURL,
+	}
+	return c.JSON(http.StatusOK, resp)
+}
 
-	uploadPath := fmt.Sprintf("public/uploads/%s", newFileName)
+// RequestPhotoUpload hands out a URL the client uploads the photo to
+// directly, bypassing this service.
+func (u *uploadImage) RequestPhotoUpload(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+		req  = request.PhotoUploadRequest{}
+	)
 
-	url, err := u.storageHandler.UploadFile(uploadPath, fileBuffer)
+	jwtUserData, err := getJwtUserData(c)
 	if err != nil {
-		log.Errorf("[uploadImage-4] UploadImage: %v", err)
+		log.Errorf("[uploadImage-1] RequestPhotoUpload: %v", err)
 		resp.Message = err.Error()
 		resp.Data = nil
-		return c.JSON(http.StatusInternalServerError, resp)
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[uploadImage-2] RequestPhotoUpload: %v", err)
+		resp.Message = err.Error()
+		resp.Data = nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[uploadImage-3] RequestPhotoUpload: %v", err)
+		resp.Message = err.Error()
+		resp.Data = nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	upload, err := u.profilePhotoService.RequestUpload(ctx, jwtUserData.UserID, req.ContentType)
+	if err != nil {
+		log.Errorf("[uploadImage-4] RequestPhotoUpload: %v", err)
+		return photoUploadErrorResponse(c, err)
+	}
+
+	resp.Message = "Success"
+	resp.Data = response.PhotoUploadResponse{
+		UploadID:  upload.ID,
+		Method:    upload.Method,
+		URL:       upload.URL,
+		Fields:    upload.Fields,
+		Headers:   upload.Headers,
+		MaxSize:   upload.MaxSize,
+		ExpiresAt: upload.ExpiresAt.Format(time.RFC3339),
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CompletePhotoUpload makes an uploaded object the profile photo once it
+// has been checked.
+func (u *uploadImage) CompletePhotoUpload(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[uploadImage-1] CompletePhotoUpload: %v", err)
+		resp.Message = err.Error()
+		resp.Data = nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Errorf("[uploadImage-2] CompletePhotoUpload: %v", err)
+		resp.Message = "invalid upload id"
+		resp.Data = nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	photo, err := u.profilePhotoService.CompleteUpload(ctx, jwtUserData.UserID, uploadID.String())
+	if err != nil {
+		log.Errorf("[uploadImage-3] CompletePhotoUpload: %v", err)
+		return photoUploadErrorResponse(c, err)
 	}
 
 	resp.Message = "Success"
-	resp.Data = map[string]string{"image_url": url}
+	resp.Data = response.ProfilePhotoResponse{
+		ImageURL:     photo.URL,
+		MediumURL:    photo.MediumURL,
+		ThumbnailURL: photo.ThumbnailURL,
+	}
 	return c.JSON(http.StatusOK, resp)
 }
 
-func getExtension(fileName string) string {
-	ext := "." + fileName[len(fileName)-3:]
-	if len(fileName) > 4 && fileName[len(fileName)-4] == '.' {
-		ext = "." + fileName[len(fileName)-4:]
+func photoUploadErrorResponse(c echo.Context, err error) error {
+	resp := response.DefaultResponse{}
+
+	switch err.Error() {
+	case "404":
+		resp.Message = "upload not found"
+		return c.JSON(http.StatusNotFound, resp)
+	case "409":
+		resp.Message = "photo has not been uploaded yet"
+		return c.JSON(http.StatusConflict, resp)
+	case "413":
+		resp.Message = "photo is too large"
+		return c.JSON(http.StatusRequestEntityTooLarge, resp)
+	case "415":
+		resp.Message = "photo must be a JPEG, PNG or WebP image"
+		return c.JSON(http.StatusUnsupportedMediaType, resp)
+	case "422":
+		resp.Message = "photo could not be read"
+		return c.JSON(http.StatusUnprocessableEntity, resp)
 	}
-	return ext
+	resp.Message = err.Error()
+	return c.JSON(http.StatusInternalServerError, resp)
 }
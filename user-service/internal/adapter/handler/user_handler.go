@@ -1,11 +1,12 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -17,22 +18,289 @@ import (
 	"user-service/internal/adapter/handler/response"
 	"user-service/internal/core/domain/entity"
 	"user-service/internal/core/service"
+	"user-service/utils/password"
+	"user-service/utils/phone"
 )
 
 type UserHandlerInterface interface {
 	SignIn(c echo.Context) error
+	RefreshToken(c echo.Context) error
 	CreateUserAccount(c echo.Context) error
 	ForgotPassword(c echo.Context) error
 	VerifyAccount(c echo.Context) error
 	UpdatePassword(c echo.Context) error
+	ChangePassword(c echo.Context) error
+	ResendVerification(c echo.Context) error
 	GetProfileUser(c echo.Context) error
 	UpdateDataUser(c echo.Context) error
+	ConfirmEmailChange(c echo.Context) error
+
+	// Admin
+	GetAllUser(c echo.Context) error
+	GetUserDetailByID(c echo.Context) error
+	GetNearbyUsers(c echo.Context) error
+	SuspendUser(c echo.Context) error
+	UnsuspendUser(c echo.Context) error
+	DeleteUser(c echo.Context) error
+	ForcePasswordReset(c echo.Context) error
+	UnlockUser(c echo.Context) error
 }
 
 type userHandler struct {
 	userService service.UserServiceInterface
 }
 
+// GetAllUser implements UserHandlerInterface.
+func (u *userHandler) GetAllUser(c echo.Context) error {
+	var (
+		resp     = response.DefaultResponseWithPaginations{}
+		respList = []response.UserListResponse{}
+		ctx      = c.Request().Context()
+	)
+
+	page, err := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	query := entity.QueryUserEntity{
+		Search:    c.QueryParam("search"),
+		Name:      c.QueryParam("name"),
+		Email:     c.QueryParam("email"),
+		RoleName:  c.QueryParam("role"),
+		Page:      page,
+		Limit:     limit,
+		OrderBy:   c.QueryParam("order_by"),
+		OrderType: c.QueryParam("order_type"),
+	}
+
+	if isVerifiedStr := c.QueryParam("is_verified"); isVerifiedStr != "" {
+		isVerified, err := strconv.ParseBool(isVerifiedStr)
+		if err != nil {
+			log.Errorf("[UserHandler-1] GetAllUser: %v", err)
+			resp.Message, resp.Data = "invalid is_verified value", nil
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		query.IsVerified = &isVerified
+	}
+
+	users, totalData, totalPage, err := u.userService.GetAllUser(ctx, query)
+	if err != nil {
+		log.Errorf("[UserHandler-2] GetAllUser: %v", err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "user not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	for _, val := range users {
+		respList = append(respList, response.UserListResponse{
+			ID:          val.ID,
+			Name:        val.Name,
+			Email:       val.Email,
+			Phone:       val.Phone,
+			Photo:       val.Photo,
+			RoleName:    val.RoleName,
+			IsVerified:  val.IsVerified,
+			IsSuspended: val.IsSuspended,
+			CreatedAt:   val.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	resp.Message = "success"
+	resp.Data = respList
+	resp.Pagination = &response.Pagination{
+		Page:       page,
+		TotalCount: totalData,
+		PerPage:    limit,
+		TotalPage:  totalPage,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetNearbyUsers implements UserHandlerInterface.
+func (u *userHandler) GetNearbyUsers(c echo.Context) error {
+	var (
+		resp     = response.DefaultResponse{}
+		respList = []response.NearbyUserResponse{}
+		ctx      = c.Request().Context()
+	)
+
+	lat, err := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		log.Errorf("[UserHandler-1] GetNearbyUsers: invalid lat %q", c.QueryParam("lat"))
+		resp.Message, resp.Data = "lat must be between -90 and 90", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	lng, err := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		log.Errorf("[UserHandler-2] GetNearbyUsers: invalid lng %q", c.QueryParam("lng"))
+		resp.Message, resp.Data = "lng must be between -180 and 180", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	query := entity.QueryNearbyUserEntity{
+		Lat:      lat,
+		Lng:      lng,
+		RoleName: c.QueryParam("role"),
+	}
+
+	if radiusStr := c.QueryParam("radius"); radiusStr != "" {
+		radius, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil || radius <= 0 {
+			log.Errorf("[UserHandler-3] GetNearbyUsers: invalid radius %q", radiusStr)
+			resp.Message, resp.Data = "radius must be a positive number of meters", nil
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		query.RadiusMeters = radius
+	}
+
+	query.Limit, err = strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	if err != nil || query.Limit <= 0 {
+		query.Limit = 10
+	}
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	users, err := u.userService.GetNearbyUsers(ctx, query)
+	if err != nil {
+		log.Errorf("[UserHandler-4] GetNearbyUsers: %v", err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "user not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	for _, val := range users {
+		respList = append(respList, response.NearbyUserResponse{
+			ID:             val.ID,
+			Name:           val.Name,
+			Email:          val.Email,
+			Phone:          val.Phone,
+			Address:        val.Address,
+			Lat:            val.Lat,
+			Lng:            val.Lng,
+			RoleName:       val.RoleName,
+			DistanceMeters: val.DistanceMeters,
+		})
+	}
+
+	resp.Message = "success"
+	resp.Data = respList
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetUserDetailByID implements UserHandlerInterface.
+func (u *userHandler) GetUserDetailByID(c echo.Context) error {
+	var (
+		resp       = response.DefaultResponse{}
+		respDetail = response.UserDetailResponse{}
+		ctx        = c.Request().Context()
+	)
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[UserHandler-1] GetUserDetailByID: %v", err)
+		resp.Message, resp.Data = "invalid user id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	user, err := u.userService.GetUserDetailByID(ctx, userID)
+	if err != nil {
+		log.Errorf("[UserHandler-2] GetUserDetailByID: %v", err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "user not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	respDetail.ID = user.ID
+	respDetail.Name = user.Name
+	respDetail.Email = user.Email
+	respDetail.Phone = user.Phone
+	respDetail.Photo = user.Photo
+	respDetail.Address = user.Address
+	respDetail.Lat = user.Lat
+	respDetail.Lng = user.Lng
+	respDetail.RoleName = user.RoleName
+	respDetail.IsVerified = user.IsVerified
+	respDetail.IsSuspended = user.IsSuspended
+	respDetail.CreatedAt = user.CreatedAt.Format(time.RFC3339)
+
+	resp.Message = "success"
+	resp.Data = respDetail
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SuspendUser implements UserHandlerInterface.
+func (u *userHandler) SuspendUser(c echo.Context) error {
+	return u.adminUserAction(c, "SuspendUser", u.userService.SuspendUser)
+}
+
+// UnsuspendUser implements UserHandlerInterface.
+func (u *userHandler) UnsuspendUser(c echo.Context) error {
+	return u.adminUserAction(c, "UnsuspendUser", u.userService.UnsuspendUser)
+}
+
+// DeleteUser implements UserHandlerInterface.
+func (u *userHandler) DeleteUser(c echo.Context) error {
+	return u.adminUserAction(c, "DeleteUser", u.userService.DeleteUser)
+}
+
+// ForcePasswordReset implements UserHandlerInterface.
+func (u *userHandler) ForcePasswordReset(c echo.Context) error {
+	return u.adminUserAction(c, "ForcePasswordReset", u.userService.ForcePasswordReset)
+}
+
+// UnlockUser implements UserHandlerInterface.
+func (u *userHandler) UnlockUser(c echo.Context) error {
+	return u.adminUserAction(c, "UnlockUser", u.userService.UnlockUser)
+}
+
+// adminUserAction runs an admin action against the user identified by the
+// :id path parameter and writes the default response.
+func (u *userHandler) adminUserAction(c echo.Context, name string, action func(ctx context.Context, userID int64) error) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Errorf("[UserHandler-1] %s: %v", name, err)
+		resp.Message, resp.Data = "invalid user id", nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = action(ctx, userID); err != nil {
+		log.Errorf("[UserHandler-2] %s: %v", name, err)
+		if err.Error() == "404" {
+			resp.Message, resp.Data = "user not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
 // UpdateDataUser implements UserHandlerInterface.
 func (u *userHandler) UpdateDataUser(c echo.Context) error {
 	var (
@@ -74,27 +342,35 @@ func (u *userHandler) UpdateDataUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, resp)
 	}
 
-	latString := strconv.FormatFloat(req.Lat, 'g', -1, 64)
-	lngString := strconv.FormatFloat(req.Lng, 'g', -1, 64)
-	phoneStr := fmt.Sprintf("%d", req.Phone)
+	phoneStr := ""
+	if req.Phone != "" {
+		// Already checked by the phone validator.
+		phoneStr, _ = phone.Normalize(req.Phone)
+	}
 
 	reqEnt := entity.UserEntity{
 		ID:      userID,
 		Name:    req.Name,
 		Email:   req.Email,
 		Address: req.Address,
-		Lat:     latString,
-		Lng:     lngString,
+		Lat:     req.Lat,
+		Lng:     req.Lng,
 		Phone:   phoneStr,
-		Photo:   req.Photo,
 	}
 
 	err = u.userService.UpdateDataUser(ctx, reqEnt)
 	if err != nil {
 		log.Errorf("[UserHandler-5] UpdateDataUser: %v", err)
-		if err.Error() == "404" {
+		switch err.Error() {
+		case "404":
 			resp.Message, resp.Data = "user not found", nil
 			return c.JSON(http.StatusNotFound, resp)
+		case "409":
+			resp.Message, resp.Data = "email already registered", nil
+			return c.JSON(http.StatusConflict, resp)
+		case "429":
+			resp.Message, resp.Data = "an email change was requested recently, please check your email or try again later", nil
+			return c.JSON(http.StatusTooManyRequests, resp)
 		}
 		resp.Message, resp.Data = err.Error(), nil
 		return c.JSON(http.StatusInternalServerError, resp)
@@ -152,6 +428,10 @@ func (u *userHandler) GetProfileUser(c echo.Context) error {
 	respProfile.Lat = dataUser.Lat
 	respProfile.Lng = dataUser.Lng
 	respProfile.Phone = dataUser.Phone
+	respProfile.PhoneVerified = dataUser.PhoneVerified
+	respProfile.PhotoMedium = dataUser.PhotoMedium
+	respProfile.PhotoThumbnail = dataUser.PhotoThumbnail
+	respProfile.PendingEmail = dataUser.PendingEmail
 	respProfile.Photo = dataUser.Photo
 	respProfile.RoleName = dataUser.RoleName
 
@@ -207,6 +487,11 @@ func (u *userHandler) UpdatePassword(c echo.Context) error {
 	err = u.userService.UpdatePassword(ctx, reqEntity)
 	if err != nil {
 		log.Infof("[UserHandler-5] UpdatePassword: %s", err)
+		if violation := passwordViolation(err); violation != nil {
+			resp.Message, resp.Data = violation.Error(), nil
+			return c.JSON(http.StatusUnprocessableEntity, resp)
+		}
+
 		if err.Error() == "404" {
 			resp.Message, resp.Data = "user not found", nil
 			return c.JSON(http.StatusNotFound, resp)
@@ -225,6 +510,102 @@ func (u *userHandler) UpdatePassword(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+// ChangePassword implements UserHandlerInterface.
+func (u *userHandler) ChangePassword(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		req  = request.UpdatePasswordRequest{}
+		ctx  = c.Request().Context()
+	)
+
+	jwtUserData, err := getJwtUserData(c)
+	if err != nil {
+		log.Errorf("[UserHandler-1] ChangePassword: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err = c.Bind(&req); err != nil {
+		log.Errorf("[UserHandler-2] ChangePassword: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+
+	if err = c.Validate(req); err != nil {
+		log.Errorf("[UserHandler-3] ChangePassword: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if req.CurrentPassword == "" {
+		resp.Message, resp.Data = "current password is required", nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if req.NewPassword != req.ConfirmPassword {
+		resp.Message, resp.Data = "password not match", nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	err = u.userService.ChangePassword(ctx, jwtUserData.UserID, jwtUserData.FamilyID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		log.Errorf("[UserHandler-4] ChangePassword: %v", err)
+		if violation := passwordViolation(err); violation != nil {
+			resp.Message, resp.Data = violation.Error(), nil
+			return c.JSON(http.StatusUnprocessableEntity, resp)
+		}
+		switch err.Error() {
+		case "401":
+			resp.Message, resp.Data = "current password is incorrect", nil
+			return c.JSON(http.StatusUnprocessableEntity, resp)
+		case "404":
+			resp.Message, resp.Data = "user not found", nil
+			return c.JSON(http.StatusNotFound, resp)
+		case "422":
+			resp.Message, resp.Data = "new password must be different from the current password", nil
+			return c.JSON(http.StatusUnprocessableEntity, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ConfirmEmailChange implements UserHandlerInterface.
+func (u *userHandler) ConfirmEmailChange(c echo.Context) error {
+	var (
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	tokenString := c.QueryParam("token")
+	if tokenString == "" {
+		err := errors.New("missing or invalid token")
+		log.Infof("[UserHandler-1] ConfirmEmailChange: %s", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnauthorized, resp)
+	}
+
+	if err := u.userService.ConfirmEmailChange(ctx, tokenString); err != nil {
+		log.Infof("[UserHandler-2] ConfirmEmailChange: %s", err)
+		switch err.Error() {
+		case "401", "404":
+			resp.Message, resp.Data = "token expired or invalid", nil
+			return c.JSON(http.StatusUnauthorized, resp)
+		case "409":
+			resp.Message, resp.Data = "email already registered", nil
+			return c.JSON(http.StatusConflict, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
 // VerifyToken implements UserHandlerInterface.
 func (u *userHandler) VerifyAccount(c echo.Context) error {
 	var (
@@ -242,7 +623,7 @@ func (u *userHandler) VerifyAccount(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, resp)
 	}
 
-	user, err := u.userService.VerifyToken(ctx, tokenString)
+	user, session, err := u.userService.VerifyToken(ctx, tokenString, sessionClient(c))
 	if err != nil {
 		log.Infof("[UserHandler-2] VerifyAccount: %s", err)
 		if err.Error() == "404" {
@@ -260,6 +641,12 @@ func (u *userHandler) VerifyAccount(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, resp)
 	}
 
+	if session.TwoFactorChallenge != "" {
+		resp.Message = "two factor authentication required"
+		resp.Data = twoFactorChallengeResponse(session)
+		return c.JSON(http.StatusOK, resp)
+	}
+
 	respSignIn.ID = user.ID
 	respSignIn.Name = user.Name
 	respSignIn.Email = user.Email
@@ -267,7 +654,9 @@ func (u *userHandler) VerifyAccount(c echo.Context) error {
 	respSignIn.Phone = user.Phone
 	respSignIn.Lat = user.Lat
 	respSignIn.Lng = user.Lng
-	respSignIn.AccessToken = user.Token
+	respSignIn.AccessToken = session.AccessToken
+	respSignIn.RefreshToken = session.RefreshToken
+	respSignIn.ExpiresIn = session.ExpiresIn
 
 	resp.Message = "success"
 	resp.Data = respSignIn
@@ -308,6 +697,48 @@ func (u *userHandler) ForgotPassword(c echo.Context) error {
 			resp.Message, resp.Data = "user not found", nil
 			return c.JSON(http.StatusInternalServerError, resp)
 		}
+		if err.Error() == "429" {
+			resp.Message, resp.Data = "a reset link was sent recently, please check your email or try again later", nil
+			return c.JSON(http.StatusTooManyRequests, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	resp.Message, resp.Data = "success", nil
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ResendVerification implements UserHandlerInterface.
+func (u *userHandler) ResendVerification(c echo.Context) error {
+	var (
+		req  = request.ForgotPasswordRequest{}
+		resp = response.DefaultResponse{}
+		ctx  = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[UserHandler-1] ResendVerification: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[UserHandler-2] ResendVerification: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := u.userService.ResendVerification(ctx, req.Email); err != nil {
+		log.Errorf("[UserHandler-3] ResendVerification: %v", err)
+		switch err.Error() {
+		case "404":
+			resp.Message, resp.Data = "no account waiting for verification with this email", nil
+			return c.JSON(http.StatusNotFound, resp)
+		case "429":
+			resp.Message, resp.Data = "a verification link was sent recently, please check your email or try again later", nil
+			return c.JSON(http.StatusTooManyRequests, resp)
+		}
 		resp.Message, resp.Data = err.Error(), nil
 		return c.JSON(http.StatusInternalServerError, resp)
 	}
@@ -354,6 +785,10 @@ func (u *userHandler) CreateUserAccount(c echo.Context) error {
 	err = u.userService.CreateUserAccount(ctx, reqEntity)
 	if err != nil {
 		log.Errorf("[UserHandler-4] CreateUserAccount: %v", err)
+		if violation := passwordViolation(err); violation != nil {
+			resp.Message, resp.Data = violation.Error(), nil
+			return c.JSON(http.StatusUnprocessableEntity, resp)
+		}
 		resp.Message = err.Error()
 		resp.Data = nil
 		return c.JSON(http.StatusInternalServerError, resp)
@@ -366,6 +801,15 @@ func (u *userHandler) CreateUserAccount(c echo.Context) error {
 
 var err error
 
+// passwordViolation returns the policy violation wrapped in err, if any.
+func passwordViolation(err error) *password.Violation {
+	violation := &password.Violation{}
+	if errors.As(err, &violation) {
+		return violation
+	}
+	return nil
+}
+
 func (u *userHandler) SignIn(c echo.Context) error {
 	var (
 		req        = request.SignInRequest{}
@@ -393,7 +837,7 @@ func (u *userHandler) SignIn(c echo.Context) error {
 		Password: req.Password,
 	}
 
-	user, token, err := u.userService.SignIn(ctx, reqEntity)
+	user, session, err := u.userService.SignIn(ctx, reqEntity, sessionClient(c))
 	if err != nil {
 
 		if err.Error() == "404" {
@@ -403,12 +847,39 @@ func (u *userHandler) SignIn(c echo.Context) error {
 			return c.JSON(http.StatusNotFound, resp)
 		}
 
-		log.Errorf("[UserHandler-4] SignIn: %v", err)
+		if err.Error() == "403" {
+			log.Errorf("[UserHandler-4] SignIn: %v", err.Error())
+			resp.Message = "account suspended"
+			resp.Data = nil
+			return c.JSON(http.StatusForbidden, resp)
+		}
+
+		if err.Error() == "423" {
+			log.Errorf("[UserHandler-5] SignIn: %v", err.Error())
+			resp.Message = "account temporarily locked, please try again later"
+			resp.Data = nil
+			return c.JSON(http.StatusLocked, resp)
+		}
+
+		if err.Error() == "429" {
+			log.Errorf("[UserHandler-6] SignIn: %v", err.Error())
+			resp.Message = "too many failed attempts, please wait before trying again"
+			resp.Data = nil
+			return c.JSON(http.StatusTooManyRequests, resp)
+		}
+
+		log.Errorf("[UserHandler-7] SignIn: %v", err)
 		resp.Message = err.Error()
 		resp.Data = nil
 		return c.JSON(http.StatusInternalServerError, resp)
 	}
 
+	if session.TwoFactorChallenge != "" {
+		resp.Message = "two factor authentication required"
+		resp.Data = twoFactorChallengeResponse(session)
+		return c.JSON(http.StatusOK, resp)
+	}
+
 	respSignIn.ID = user.ID
 	respSignIn.Name = user.Name
 	respSignIn.Email = user.Email
@@ -416,7 +887,9 @@ func (u *userHandler) SignIn(c echo.Context) error {
 	respSignIn.Phone = user.Phone
 	respSignIn.Lat = user.Lat
 	respSignIn.Lng = user.Lng
-	respSignIn.AccessToken = token
+	respSignIn.AccessToken = session.AccessToken
+	respSignIn.RefreshToken = session.RefreshToken
+	respSignIn.ExpiresIn = session.ExpiresIn
 
 	resp.Message = "success"
 	resp.Data = respSignIn
@@ -424,6 +897,51 @@ func (u *userHandler) SignIn(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+// RefreshToken implements UserHandlerInterface.
+func (u *userHandler) RefreshToken(c echo.Context) error {
+	var (
+		req       = request.RefreshTokenRequest{}
+		resp      = response.DefaultResponse{}
+		respToken = response.TokenResponse{}
+		ctx       = c.Request().Context()
+	)
+
+	if err := c.Bind(&req); err != nil {
+		log.Errorf("[UserHandler-1] RefreshToken: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Errorf("[UserHandler-2] RefreshToken: %v", err)
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusUnprocessableEntity, resp)
+	}
+
+	session, err := u.userService.RefreshToken(ctx, req.RefreshToken, sessionClient(c))
+	if err != nil {
+		log.Errorf("[UserHandler-3] RefreshToken: %v", err)
+		switch err.Error() {
+		case "401", "404":
+			resp.Message, resp.Data = "refresh token expired or invalid", nil
+			return c.JSON(http.StatusUnauthorized, resp)
+		case "403":
+			resp.Message, resp.Data = "account suspended", nil
+			return c.JSON(http.StatusForbidden, resp)
+		}
+		resp.Message, resp.Data = err.Error(), nil
+		return c.JSON(http.StatusInternalServerError, resp)
+	}
+
+	respToken.AccessToken = session.AccessToken
+	respToken.RefreshToken = session.RefreshToken
+	respToken.ExpiresIn = session.ExpiresIn
+
+	resp.Message = "success"
+	resp.Data = respToken
+	return c.JSON(http.StatusOK, resp)
+}
+
 func NewUserHandler(e *echo.Echo, userService service.UserServiceInterface, cfg *config.Config, jws service.JwtServiceInterface) UserHandlerInterface {
 	userHandler := &userHandler{
 		userService: userService,
@@ -434,18 +952,32 @@ func NewUserHandler(e *echo.Echo, userService service.UserServiceInterface, cfg
 	})
 
 	e.Use(middleware.Recover())
-	e.POST("/signin", userHandler.SignIn)
-	e.POST("/signup", userHandler.CreateUserAccount)
-	e.POST("/forgot-password", userHandler.ForgotPassword)
-	e.GET("/verify-account", userHandler.VerifyAccount)
-	e.GET("/update-password", userHandler.UpdatePassword)
 
 	mid := adapter.NewMiddlewareAdapter(cfg, jws)
-	adminGroup := e.Group("/admin", mid.CheckToken())
-	adminGroup.GET("/profile", userHandler.GetProfileUser)
 
-	authGroup := e.Group("/auth", mid.CheckToken())
+	e.POST("/signin", userHandler.SignIn, mid.RateLimit("public"))
+	e.POST("/refresh", userHandler.RefreshToken, mid.RateLimit("public"))
+	e.POST("/signup", userHandler.CreateUserAccount, mid.RateLimit("email"))
+	e.POST("/forgot-password", userHandler.ForgotPassword, mid.RateLimit("email"))
+	e.POST("/resend-verification", userHandler.ResendVerification, mid.RateLimit("email"))
+	e.GET("/verify-account", userHandler.VerifyAccount, mid.RateLimit("public"))
+	e.GET("/confirm-email", userHandler.ConfirmEmailChange, mid.RateLimit("public"))
+	e.PUT("/update-password", userHandler.UpdatePassword, mid.RateLimit("public"))
+
+	adminGroup := e.Group("/admin", mid.CheckToken(), mid.RequireRole("Super Admin"), mid.RateLimit("admin"))
+	adminGroup.GET("/profile", userHandler.GetProfileUser)
+	adminGroup.GET("/users", userHandler.GetAllUser)
+	adminGroup.GET("/users/nearby", userHandler.GetNearbyUsers)
+	adminGroup.GET("/users/:id", userHandler.GetUserDetailByID)
+	adminGroup.PUT("/users/:id/suspend", userHandler.SuspendUser)
+	adminGroup.PUT("/users/:id/unsuspend", userHandler.UnsuspendUser)
+	adminGroup.DELETE("/users/:id", userHandler.DeleteUser)
+	adminGroup.POST("/users/:id/reset-password", userHandler.ForcePasswordReset)
+	adminGroup.POST("/users/:id/unlock", userHandler.UnlockUser)
+
+	authGroup := e.Group("/auth", mid.CheckToken(), mid.RateLimit("auth"))
 	authGroup.PUT("/profile", userHandler.UpdateDataUser)
+	authGroup.PUT("/password", userHandler.ChangePassword)
 
 	return userHandler
 
@@ -0,0 +1,33 @@
+package message
+
+import (
+	"encoding/json"
+	"time"
+
+	"user-service/internal/core/domain/entity"
+)
+
+// UserEventsExchange is the topic exchange other services bind to for user
+// lifecycle events, e.g. "user.deleted".
+const UserEventsExchange = "user.events"
+
+const EventUserDeleted = "user.deleted"
+
+// UserEvent builds the outbox event that publishes payload to
+// UserEventsExchange with eventType as the routing key.
+func UserEvent(eventType string, payload map[string]interface{}) (entity.OutboxEventEntity, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":        eventType,
+		"occurred_at": time.Now().UTC(),
+		"data":        payload,
+	})
+	if err != nil {
+		return entity.OutboxEventEntity{}, err
+	}
+
+	return entity.OutboxEventEntity{
+		Exchange:   UserEventsExchange,
+		RoutingKey: eventType,
+		Payload:    body,
+	}, nil
+}
@@ -0,0 +1,177 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/streadway/amqp"
+
+	"user-service/config"
+	"user-service/internal/core/domain/entity"
+)
+
+// publishConfirmTimeout bounds how long Publish waits for RabbitMQ to
+// confirm a message.
+const publishConfirmTimeout = 10 * time.Second
+
+// PublisherInterface publishes outbox events over one long lived channel.
+// Publish only returns nil once RabbitMQ has confirmed the message, so a
+// failed publish can safely be retried. Consumers may still see a message
+// twice and can use its message id to tell.
+type PublisherInterface interface {
+	Publish(ctx context.Context, event entity.OutboxEventEntity) error
+	Close() error
+}
+
+type publisher struct {
+	cfg      *config.Config
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	confirms chan amqp.Confirmation
+	declared map[string]bool
+}
+
+// Publish implements PublisherInterface.
+func (p *publisher) Publish(ctx context.Context, event entity.OutboxEventEntity) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.connect(); err != nil {
+		log.Errorf("[Publisher-1] Publish: %v", err)
+		return err
+	}
+
+	if err := p.declare(event.Exchange, event.RoutingKey); err != nil {
+		log.Errorf("[Publisher-2] Publish: %v", err)
+		p.reset()
+		return err
+	}
+
+	err := p.ch.Publish(
+		event.Exchange,
+		event.RoutingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    strconv.FormatInt(event.ID, 10),
+			Timestamp:    event.CreatedAt,
+			Body:         event.Payload,
+		},
+	)
+	if err != nil {
+		log.Errorf("[Publisher-3] Publish: %v", err)
+		p.reset()
+		return err
+	}
+
+	// Confirmations arrive in publish order and only one publish is in
+	// flight, so the next one belongs to this message. Anything that leaves
+	// it unread resets the channel to keep that true.
+	select {
+	case confirm, ok := <-p.confirms:
+		if !ok {
+			p.reset()
+			return errors.New("channel closed before the message was confirmed")
+		}
+		if !confirm.Ack {
+			return fmt.Errorf("message %d was rejected by the broker", event.ID)
+		}
+		return nil
+	case <-time.After(publishConfirmTimeout):
+		p.reset()
+		return fmt.Errorf("message %d was not confirmed within %s", event.ID, publishConfirmTimeout)
+	case <-ctx.Done():
+		p.reset()
+		return ctx.Err()
+	}
+}
+
+// Close implements PublisherInterface.
+func (p *publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+
+	err := p.conn.Close()
+	p.conn, p.ch, p.confirms = nil, nil, nil
+	return err
+}
+
+// connect opens the connection and a channel in confirm mode, unless they
+// are still usable.
+func (p *publisher) connect() error {
+	if p.ch != nil && !p.conn.IsClosed() {
+		return nil
+	}
+	p.reset()
+
+	conn, err := p.cfg.NewRabbitMQ()
+	if err != nil {
+		return err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	if err = ch.Confirm(false); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn, p.ch = conn, ch
+	p.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	p.declared = map[string]bool{}
+	return nil
+}
+
+// declare makes sure the destination exists. The default exchange routes
+// straight to the durable queue named by routingKey, any other exchange is
+// a durable topic exchange.
+func (p *publisher) declare(exchange, routingKey string) error {
+	key := exchange + "/" + routingKey
+	if exchange != "" {
+		key = exchange
+	}
+	if p.declared[key] {
+		return nil
+	}
+
+	var err error
+	if exchange == "" {
+		_, err = p.ch.QueueDeclare(routingKey, true, false, false, false, nil)
+	} else {
+		err = p.ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.declared[key] = true
+	return nil
+}
+
+// reset drops the connection so the next Publish starts over.
+func (p *publisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.ch, p.confirms = nil, nil, nil
+}
+
+func NewPublisher(cfg *config.Config) PublisherInterface {
+	return &publisher{cfg: cfg}
+}
@@ -3,61 +3,38 @@ package message
 import (
 	"encoding/json"
 
-	"github.com/labstack/gommon/log"
-	"github.com/streadway/amqp"
-
-	"user-service/config"
+	"user-service/internal/core/domain/entity"
 )
 
-func PublishMessage(email, msg, notifType string) error {
-	conn, err := config.NewConfig().NewRabbitMQ()
-	if err != nil {
-		log.Errorf("[PublishMessage-1] failed to connect to RabbitMQ: %v", err)
-		return err
-	}
-
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Errorf("[PublishMessage-2] failed to open a channel: %v", err)
-		return err
-	}
-
-	defer ch.Close()
+// NotificationsQueue is consumed by notification-service.
+const NotificationsQueue = "notifications"
 
-	queue, err := ch.QueueDeclare(
-		"notifications",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		log.Errorf("[PublishMessage-3] failed to declare a queue: %v", err)
-		return err
-	}
-
-	notification := map[string]string{
+// NotificationEvent builds the outbox event for an email to email.
+func NotificationEvent(email, msg string) (entity.OutboxEventEntity, error) {
+	return notificationEvent(map[string]string{
 		"email": email,
 		"msg":   msg,
-	}
+	})
+}
+
+// SMSEvent builds the outbox event for a text message to phone, which must
+// be in E.164 format.
+func SMSEvent(phone, msg string) (entity.OutboxEventEntity, error) {
+	return notificationEvent(map[string]string{
+		"channel": "sms",
+		"phone":   phone,
+		"msg":     msg,
+	})
+}
 
+func notificationEvent(notification map[string]string) (entity.OutboxEventEntity, error) {
 	body, err := json.Marshal(notification)
 	if err != nil {
-		log.Errorf("[PublishMessage-4] failed to marshal notification: %v", err)
-		return err
+		return entity.OutboxEventEntity{}, err
 	}
 
-	return ch.Publish(
-		"",
-		queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
+	return entity.OutboxEventEntity{
+		RoutingKey: NotificationsQueue,
+		Payload:    body,
+	}, nil
 }
@@ -1,26 +1,69 @@
 package adapter
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
 
 	"user-service/config"
 	"user-service/internal/adapter/handler/response"
+	"user-service/internal/core/domain/entity"
 	"user-service/internal/core/service"
 )
 
 type MiddlewareAdapterInterface interface {
 	CheckToken() echo.MiddlewareFunc
+	RequireRole(roles ...string) echo.MiddlewareFunc
+	RateLimit(group string) echo.MiddlewareFunc
+	AuditContext() echo.MiddlewareFunc
 }
 
 type middlewareAdapter struct {
 	cfg        *config.Config
 	jwtService service.JwtServiceInterface
+	redis      *redis.Client
 }
 
+// tokenBucketScript refills the bucket for the time elapsed since the last
+// request and takes one token. It returns {allowed, retry_after_ms}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate) + 1000)
+return {allowed, retry}
+`)
+
 func (m *middlewareAdapter) CheckToken() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -58,14 +101,153 @@ func (m *middlewareAdapter) CheckToken() echo.MiddlewareFunc {
 			}
 
 			c.Set("user", getSession)
+
+			jwtUserData := entity.JwtUserData{}
+			if err = json.Unmarshal([]byte(getSession), &jwtUserData); err == nil {
+				c.SetRequest(c.Request().WithContext(service.WithAuditActor(c.Request().Context(), jwtUserData.UserID)))
+			}
+
 			return next(c)
 		}
 	}
 }
 
+// AuditContext records the client's IP address and user agent in the
+// request context for the audit log. CheckToken adds the user.
+func (m *middlewareAdapter) AuditContext() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := service.WithAuditClient(c.Request().Context(), c.RealIP(), c.Request().UserAgent())
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// RequireRole must be chained after CheckToken. It allows the request when the
+// user holds at least one of the given roles, read from the session stored by
+// CheckToken or, for sessions created without roles, from the JWT claims.
+func (m *middlewareAdapter) RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			respErr := response.DefaultResponse{}
+
+			session, ok := c.Get("user").(string)
+			if !ok || session == "" {
+				log.Errorf("[Middleware-1] RequireRole: session missing")
+				respErr.Message = "session missing"
+				respErr.Data = nil
+				return c.JSON(http.StatusUnauthorized, respErr)
+			}
+
+			jwtUserData := entity.JwtUserData{}
+			if err := json.Unmarshal([]byte(session), &jwtUserData); err != nil {
+				log.Errorf("[Middleware-2] RequireRole: %v", err)
+				respErr.Message = err.Error()
+				respErr.Data = nil
+				return c.JSON(http.StatusUnauthorized, respErr)
+			}
+
+			userRoles := jwtUserData.Roles
+			if len(userRoles) == 0 {
+				tokenString := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+				userRoles = m.rolesFromToken(tokenString)
+			}
+
+			for _, userRole := range userRoles {
+				for _, role := range roles {
+					if strings.EqualFold(userRole, role) {
+						return next(c)
+					}
+				}
+			}
+
+			log.Errorf("[Middleware-3] RequireRole: user %d lacks roles %v", jwtUserData.UserID, roles)
+			respErr.Message = "forbidden"
+			respErr.Data = nil
+			return c.JSON(http.StatusForbidden, respErr)
+		}
+	}
+}
+
+// RateLimit applies the token bucket configured for group. Requests are keyed
+// by user ID when the middleware runs after CheckToken and by c.RealIP
+// otherwise. Buckets live in Redis so limits hold across replicas; if Redis
+// is unavailable the request is let through.
+func (m *middlewareAdapter) RateLimit(group string) echo.MiddlewareFunc {
+	rule, ok := m.cfg.RateLimit.Rules[group]
+	if !m.cfg.RateLimit.Enabled || !ok || rule.Requests <= 0 || rule.Period <= 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+
+	// tokens per millisecond
+	rate := float64(rule.Requests) / float64(rule.Period.Milliseconds())
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			subject := "ip:" + c.RealIP()
+			if session, ok := c.Get("user").(string); ok && session != "" {
+				jwtUserData := entity.JwtUserData{}
+				if err := json.Unmarshal([]byte(session), &jwtUserData); err == nil {
+					subject = fmt.Sprintf("user:%d", jwtUserData.UserID)
+				}
+			}
+
+			key := fmt.Sprintf("rate_limit:%s:%s", group, subject)
+			result, err := tokenBucketScript.Run(c.Request().Context(), m.redis, []string{key},
+				rule.Requests, rate, time.Now().UnixMilli()).Int64Slice()
+			if err != nil {
+				log.Errorf("[Middleware-1] RateLimit: %v", err)
+				return next(c)
+			}
+
+			if result[0] == 1 {
+				return next(c)
+			}
+
+			retryAfter := int64(math.Ceil(float64(result[1]) / 1000))
+			c.Response().Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+
+			log.Infof("[Middleware-2] RateLimit: %s exceeded for %s", group, subject)
+			respErr := response.DefaultResponse{}
+			respErr.Message = "too many requests"
+			respErr.Data = nil
+			return c.JSON(http.StatusTooManyRequests, respErr)
+		}
+	}
+}
+
+func (m *middlewareAdapter) rolesFromToken(tokenString string) []string {
+	token, err := m.jwtService.ValidateToken(tokenString)
+	if err != nil {
+		return nil
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+
+	rawRoles, ok := claims["roles"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	roles := make([]string, 0, len(rawRoles))
+	for _, rawRole := range rawRoles {
+		if role, ok := rawRole.(string); ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
 func NewMiddlewareAdapter(cfg *config.Config, jwtService service.JwtServiceInterface) MiddlewareAdapterInterface {
 	return &middlewareAdapter{
 		cfg:        cfg,
 		jwtService: jwtService,
+		redis:      config.NewRedisClient(),
 	}
 }
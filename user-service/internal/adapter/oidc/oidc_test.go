@@ -0,0 +1,253 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"user-service/config"
+)
+
+const (
+	testClientID     = "user-service"
+	testClientSecret = "secret"
+	testRedirectURL  = "https://app.example.com/oauth/callback"
+	testCode         = "auth-code"
+	testVerifier     = "code-verifier"
+	testNonce        = "nonce"
+)
+
+// mockProvider is an OpenID Connect provider serving discovery, a JWKS
+// with one RSA key and a token endpoint that returns idToken.
+type mockProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	kid       string
+	idToken   string
+	jwksCalls atomic.Int32
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	m := &mockProvider{key: key, kid: "key-1"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, discoveryDocument{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JwksURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.jwksCalls.Add(1)
+		writeJSON(w, map[string][]jsonWebKey{"keys": {{
+			Kty: "RSA",
+			Kid: m.kid,
+			N:   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if r.PostForm.Get("grant_type") != "authorization_code" ||
+			r.PostForm.Get("code") != testCode ||
+			r.PostForm.Get("code_verifier") != testVerifier ||
+			r.PostForm.Get("client_id") != testClientID ||
+			r.PostForm.Get("client_secret") != testClientSecret ||
+			r.PostForm.Get("redirect_uri") != testRedirectURL {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, map[string]string{"id_token": m.idToken, "token_type": "Bearer"})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+// sign makes an ID token signed with the provider key under kid. Claims
+// override the defaults of a valid token.
+func (m *mockProvider) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	defaults := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"email":          "Jane@Example.com",
+		"email_verified": true,
+		"name":           "Jane",
+		"nonce":          testNonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		defaults[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, defaults)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+func (m *mockProvider) provider() *provider {
+	providers := NewProviders(&config.Config{OIDC: map[string]config.OIDCProvider{
+		"mock": {
+			Issuer:       m.server.URL,
+			ClientID:     testClientID,
+			ClientSecret: testClientSecret,
+			RedirectURL:  testRedirectURL,
+		},
+	}})
+	return providers["mock"].(*provider)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockProvider(t)
+
+	authURL, err := m.provider().AuthCodeURL(context.Background(), "state", testNonce, "challenge")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	if !strings.HasPrefix(authURL, m.server.URL+"/authorize?") {
+		t.Fatalf("AuthCodeURL = %s, want the discovered authorization endpoint", authURL)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse %s: %v", authURL, err)
+	}
+
+	query := parsed.Query()
+	for name, want := range map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state",
+		"nonce":                 testNonce,
+		"code_challenge":        "challenge",
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	m.idToken = m.sign(t, m.kid, jwt.MapClaims{"email_verified": "true"})
+
+	identity, err := m.provider().Exchange(context.Background(), testCode, testVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if identity.Provider != "mock" || identity.Subject != "subject-1" || identity.Email != "Jane@Example.com" ||
+		identity.Name != "Jane" || !identity.EmailVerified {
+		t.Fatalf("Exchange = %+v", identity)
+	}
+}
+
+func TestExchangeRejectsInvalidTokens(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+
+	tests := []struct {
+		name     string
+		verifier string
+		claims   jwt.MapClaims
+	}{
+		{name: "wrong code verifier", verifier: "other-verifier"},
+		{name: "nonce mismatch", claims: jwt.MapClaims{"nonce": "other-nonce"}},
+		{name: "wrong audience", claims: jwt.MapClaims{"aud": "other-client"}},
+		{name: "wrong issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{name: "expired", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}},
+		{name: "missing subject", claims: jwt.MapClaims{"sub": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := tt.verifier
+			if verifier == "" {
+				verifier = testVerifier
+			}
+			m.idToken = m.sign(t, m.kid, tt.claims)
+
+			_, err := p.Exchange(context.Background(), testCode, verifier, testNonce)
+			if err == nil || err.Error() != "401" {
+				t.Fatalf("Exchange error = %v, want 401", err)
+			}
+		})
+	}
+}
+
+func TestExchangeRefreshesKeysAtMostOncePerInterval(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	ctx := context.Background()
+
+	m.idToken = m.sign(t, "unknown", nil)
+	for i := 0; i < 3; i++ {
+		if _, err := p.Exchange(ctx, testCode, testVerifier, testNonce); err == nil {
+			t.Fatalf("Exchange with an unknown kid succeeded")
+		}
+	}
+
+	if calls := m.jwksCalls.Load(); calls != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", calls)
+	}
+
+	// The provider rolls its key after the interval has passed.
+	m.kid = "unknown"
+	p.mu.Lock()
+	p.keysRefreshedAt = time.Now().Add(-jwksMinRefreshInterval)
+	p.mu.Unlock()
+
+	if _, err := p.Exchange(ctx, testCode, testVerifier, testNonce); err != nil {
+		t.Fatalf("Exchange after key rotation: %v", err)
+	}
+
+	if calls := m.jwksCalls.Load(); calls != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", calls)
+	}
+}
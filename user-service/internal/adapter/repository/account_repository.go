@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/domain/model"
+)
+
+type AccountRepositoryInterface interface {
+	GetExportData(ctx context.Context, userID int64) (*entity.AccountExportEntity, error)
+	GetExpiredDeletedUsers(ctx context.Context, deletedBefore time.Time, limit int) ([]entity.UserEntity, error)
+	AnonymizeUser(ctx context.Context, userID int64) error
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+// GetExportData implements AccountRepositoryInterface.
+func (a *accountRepository) GetExportData(ctx context.Context, userID int64) (*entity.AccountExportEntity, error) {
+	modelUser := model.User{}
+
+	if err := a.db.Where("id = ? AND deleted_at IS NULL", userID).Preload("Roles").First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[AccountRepository-1] GetExportData: %v", err)
+			return nil, err
+		}
+
+		log.Errorf("[AccountRepository-2] GetExportData: %v", err)
+		return nil, err
+	}
+
+	modelIdentities := []model.UserIdentity{}
+	if err := a.db.Where("user_id = ?", userID).Order("created_at").Find(&modelIdentities).Error; err != nil {
+		log.Errorf("[AccountRepository-3] GetExportData: %v", err)
+		return nil, err
+	}
+
+	modelTokens := []model.VerificationToken{}
+	if err := a.db.Where("user_id = ?", userID).Order("created_at").Find(&modelTokens).Error; err != nil {
+		log.Errorf("[AccountRepository-4] GetExportData: %v", err)
+		return nil, err
+	}
+
+	// Removed addresses are kept for past orders, so they are exported too.
+	modelAddresses := []model.UserAddress{}
+	if err := a.db.Where("user_id = ?", userID).Order("created_at").Find(&modelAddresses).Error; err != nil {
+		log.Errorf("[AccountRepository-5] GetExportData: %v", err)
+		return nil, err
+	}
+
+	export := entity.AccountExportEntity{
+		Profile: toUserEntity(modelUser),
+	}
+
+	for _, val := range modelIdentities {
+		export.Identities = append(export.Identities, entity.AccountIdentityEntity{
+			Provider:  val.Provider,
+			Email:     val.Email,
+			CreatedAt: val.CreatedAt,
+		})
+	}
+
+	for _, val := range modelTokens {
+		token := toVerificationTokenEntity(val)
+		token.Token = ""
+		export.Tokens = append(export.Tokens, *token)
+	}
+
+	for _, val := range modelAddresses {
+		export.Addresses = append(export.Addresses, toAddressEntity(val))
+	}
+
+	return &export, nil
+}
+
+// GetExpiredDeletedUsers implements AccountRepositoryInterface.
+// Only the ID and PhotoPath of the returned users are set.
+func (a *accountRepository) GetExpiredDeletedUsers(ctx context.Context, deletedBefore time.Time, limit int) ([]entity.UserEntity, error) {
+	modelUsers := []model.User{}
+
+	if err := a.db.Select("id", "photo_path").
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL", deletedBefore).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&modelUsers).Error; err != nil {
+		log.Errorf("[AccountRepository-1] GetExpiredDeletedUsers: %v", err)
+		return nil, err
+	}
+
+	users := make([]entity.UserEntity, 0, len(modelUsers))
+	for _, val := range modelUsers {
+		users = append(users, entity.UserEntity{
+			ID:        val.ID,
+			PhotoPath: val.PhotoPath,
+		})
+	}
+
+	return users, nil
+}
+
+// AnonymizeUser implements AccountRepositoryInterface.
+// Personal data is overwritten rather than the row removed, so ids
+// referenced by other services stay valid. The email keeps the unique
+// constraint satisfied while freeing the original address.
+func (a *accountRepository) AnonymizeUser(ctx context.Context, userID int64) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"name":               "Deleted User",
+				"email":              gorm.Expr("'deleted-' || id || '@users.invalid'"),
+				"pending_email":      gorm.Expr("NULL"),
+				"password":           "",
+				"phone":              gorm.Expr("NULL"),
+				"phone_verified":     false,
+				"photo":              gorm.Expr("NULL"),
+				"photo_medium":       gorm.Expr("NULL"),
+				"photo_thumbnail":    gorm.Expr("NULL"),
+				"photo_path":         gorm.Expr("NULL"),
+				"address":            gorm.Expr("NULL"),
+				"lat":                gorm.Expr("NULL"),
+				"lng":                gorm.Expr("NULL"),
+				"two_factor_secret":  gorm.Expr("NULL"),
+				"two_factor_enabled": false,
+				"anonymized_at":      time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("404")
+		}
+
+		for _, value := range []interface{}{
+			&model.UserIdentity{},
+			&model.RecoveryCode{},
+			&model.PasswordHistory{},
+			&model.VerificationToken{},
+			&model.UserAddress{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(value).Error; err != nil {
+				return err
+			}
+		}
+
+		// Audit entries stay for the record but lose what identifies the user.
+		err := tx.Model(&model.AuditLog{}).
+			Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userID, entity.AuditTargetUser, userID).
+			Updates(map[string]interface{}{
+				"changes":    gorm.Expr("NULL"),
+				"metadata":   gorm.Expr("NULL"),
+				"ip_address": gorm.Expr("NULL"),
+				"user_agent": gorm.Expr("NULL"),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Errorf("[AccountRepository-1] AnonymizeUser: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepositoryInterface {
+	return &accountRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/domain/model"
+)
+
+type AddressRepositoryInterface interface {
+	GetAll(ctx context.Context, userID int64) ([]entity.AddressEntity, error)
+	GetByID(ctx context.Context, userID, id int64) (*entity.AddressEntity, error)
+	Create(ctx context.Context, req entity.AddressEntity, limit int64) (*entity.AddressEntity, error)
+	Update(ctx context.Context, req entity.AddressEntity) error
+	Delete(ctx context.Context, userID, id int64) error
+	SetDefault(ctx context.Context, userID, id int64) error
+}
+
+type addressRepository struct {
+	db *gorm.DB
+}
+
+// GetAll implements AddressRepositoryInterface.
+// The default address comes first, then the most recently added.
+func (a *addressRepository) GetAll(ctx context.Context, userID int64) ([]entity.AddressEntity, error) {
+	modelAddresses := []model.UserAddress{}
+
+	if err := a.db.Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order("is_default DESC, created_at DESC").
+		Find(&modelAddresses).Error; err != nil {
+		log.Errorf("[AddressRepository-1] GetAll: %v", err)
+		return nil, err
+	}
+
+	if len(modelAddresses) == 0 {
+		err := errors.New("404")
+		log.Infof("[AddressRepository-2] GetAll: %v", err)
+		return nil, err
+	}
+
+	entities := []entity.AddressEntity{}
+	for _, val := range modelAddresses {
+		entities = append(entities, toAddressEntity(val))
+	}
+
+	return entities, nil
+}
+
+// GetByID implements AddressRepositoryInterface.
+func (a *addressRepository) GetByID(ctx context.Context, userID, id int64) (*entity.AddressEntity, error) {
+	modelAddress := model.UserAddress{}
+
+	if err := a.db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).First(&modelAddress).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[AddressRepository-1] GetByID: %v", err)
+			return nil, err
+		}
+
+		log.Errorf("[AddressRepository-2] GetByID: %v", err)
+		return nil, err
+	}
+
+	address := toAddressEntity(modelAddress)
+	return &address, nil
+}
+
+// Create implements AddressRepositoryInterface.
+// It returns 422 when the user already has limit addresses. The user row is
+// locked while counting, so concurrent requests cannot go over the limit
+// and only one of them can add the first address, which always becomes the
+// default. A new default address takes the flag from the previous one.
+func (a *addressRepository) Create(ctx context.Context, req entity.AddressEntity, limit int64) (*entity.AddressEntity, error) {
+	modelAddress := toAddressModel(req)
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ? AND deleted_at IS NULL", req.UserID).First(&model.User{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("404")
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.UserAddress{}).Where("user_id = ? AND deleted_at IS NULL", req.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count >= limit {
+			return errors.New("422")
+		}
+
+		if count == 0 {
+			modelAddress.IsDefault = true
+		}
+
+		if modelAddress.IsDefault {
+			if err := clearDefaultAddress(tx, req.UserID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&modelAddress).Error
+	})
+	if err != nil {
+		if err.Error() == "404" || err.Error() == "422" {
+			log.Infof("[AddressRepository-1] Create: %v", err)
+			return nil, err
+		}
+		log.Errorf("[AddressRepository-2] Create: %v", err)
+		return nil, err
+	}
+
+	address := toAddressEntity(modelAddress)
+	return &address, nil
+}
+
+// Update implements AddressRepositoryInterface.
+// IsDefault is not changed here, see SetDefault.
+func (a *addressRepository) Update(ctx context.Context, req entity.AddressEntity) error {
+	result := a.db.Model(&model.UserAddress{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NULL", req.ID, req.UserID).
+		Updates(map[string]interface{}{
+			"label":          req.Label,
+			"recipient_name": req.RecipientName,
+			"phone":          req.Phone,
+			"street":         req.Street,
+			"province":       req.Province,
+			"city":           req.City,
+			"district":       req.District,
+			"postal_code":    req.PostalCode,
+			"notes":          req.Notes,
+			"latitude":       req.Latitude,
+			"longitude":      req.Longitude,
+			"updated_at":     time.Now(),
+		})
+	if result.Error != nil {
+		log.Errorf("[AddressRepository-1] Update: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		err := errors.New("404")
+		log.Infof("[AddressRepository-2] Update: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Delete implements AddressRepositoryInterface.
+// Deleting the default address makes the most recent remaining one the
+// default.
+func (a *addressRepository) Delete(ctx context.Context, userID, id int64) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		modelAddress := model.UserAddress{}
+		if err := tx.Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).First(&modelAddress).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("404")
+			}
+			return err
+		}
+
+		if err := tx.Model(&modelAddress).Updates(map[string]interface{}{
+			"is_default": false,
+			"deleted_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		if !modelAddress.IsDefault {
+			return nil
+		}
+
+		next := model.UserAddress{}
+		err := tx.Where("user_id = ? AND deleted_at IS NULL", userID).Order("created_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+	if err != nil {
+		log.Errorf("[AddressRepository-1] Delete: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetDefault implements AddressRepositoryInterface.
+func (a *addressRepository) SetDefault(ctx context.Context, userID, id int64) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.UserAddress{}).Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("404")
+		}
+
+		if err := clearDefaultAddress(tx, userID); err != nil {
+			return err
+		}
+
+		return tx.Model(&model.UserAddress{}).Where("id = ?", id).Update("is_default", true).Error
+	})
+	if err != nil {
+		log.Errorf("[AddressRepository-1] SetDefault: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func clearDefaultAddress(tx *gorm.DB, userID int64) error {
+	return tx.Model(&model.UserAddress{}).
+		Where("user_id = ? AND is_default AND deleted_at IS NULL", userID).
+		Update("is_default", false).Error
+}
+
+func toAddressModel(req entity.AddressEntity) model.UserAddress {
+	return model.UserAddress{
+		ID:            req.ID,
+		UserID:        req.UserID,
+		Label:         req.Label,
+		RecipientName: req.RecipientName,
+		Phone:         req.Phone,
+		Street:        req.Street,
+		Province:      req.Province,
+		City:          req.City,
+		District:      req.District,
+		PostalCode:    req.PostalCode,
+		Notes:         req.Notes,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		IsDefault:     req.IsDefault,
+	}
+}
+
+func toAddressEntity(modelAddress model.UserAddress) entity.AddressEntity {
+	return entity.AddressEntity{
+		ID:            modelAddress.ID,
+		UserID:        modelAddress.UserID,
+		Label:         modelAddress.Label,
+		RecipientName: modelAddress.RecipientName,
+		Phone:         modelAddress.Phone,
+		Street:        modelAddress.Street,
+		Province:      modelAddress.Province,
+		City:          modelAddress.City,
+		District:      modelAddress.District,
+		PostalCode:    modelAddress.PostalCode,
+		Notes:         modelAddress.Notes,
+		Latitude:      modelAddress.Latitude,
+		Longitude:     modelAddress.Longitude,
+		IsDefault:     modelAddress.IsDefault,
+		CreatedAt:     modelAddress.CreatedAt,
+		UpdatedAt:     modelAddress.UpdatedAt,
+		DeletedAt:     modelAddress.DeletedAt,
+	}
+}
+
+func NewAddressRepository(db *gorm.DB) AddressRepositoryInterface {
+	return &addressRepository{
+		db: db,
+	}
+}
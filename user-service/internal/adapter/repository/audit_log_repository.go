@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/domain/model"
+)
+
+type AuditLogRepositoryInterface interface {
+	Create(ctx context.Context, req entity.AuditLogEntity) error
+	GetAll(ctx context.Context, query entity.QueryAuditLogEntity) ([]entity.AuditLogEntity, int64, int64, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// Create implements AuditLogRepositoryInterface.
+func (a *auditLogRepository) Create(ctx context.Context, req entity.AuditLogEntity) error {
+	modelAuditLog := model.AuditLog{
+		Action:    req.Action,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}
+
+	if req.ActorID != 0 {
+		modelAuditLog.ActorID = &req.ActorID
+	}
+
+	if req.TargetType != "" {
+		modelAuditLog.TargetType = &req.TargetType
+		modelAuditLog.TargetID = &req.TargetID
+	}
+
+	if len(req.Changes) > 0 {
+		changes, err := json.Marshal(req.Changes)
+		if err != nil {
+			log.Errorf("[AuditLogRepository-1] Create: %v", err)
+			return err
+		}
+		changesStr := string(changes)
+		modelAuditLog.Changes = &changesStr
+	}
+
+	if len(req.Metadata) > 0 {
+		metadata, err := json.Marshal(req.Metadata)
+		if err != nil {
+			log.Errorf("[AuditLogRepository-2] Create: %v", err)
+			return err
+		}
+		metadataStr := string(metadata)
+		modelAuditLog.Metadata = &metadataStr
+	}
+
+	if err := a.db.Create(&modelAuditLog).Error; err != nil {
+		log.Errorf("[AuditLogRepository-3] Create: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetAll implements AuditLogRepositoryInterface.
+// Entries are returned newest first.
+func (a *auditLogRepository) GetAll(ctx context.Context, query entity.QueryAuditLogEntity) ([]entity.AuditLogEntity, int64, int64, error) {
+	modelAuditLogs := []model.AuditLog{}
+	var countData int64
+
+	sqlMain := a.db.Model(&model.AuditLog{})
+
+	if query.Action != "" {
+		sqlMain = sqlMain.Where("action = ?", query.Action)
+	}
+
+	if query.ActorID != 0 {
+		sqlMain = sqlMain.Where("actor_id = ?", query.ActorID)
+	}
+
+	if query.TargetType != "" {
+		sqlMain = sqlMain.Where("target_type = ?", query.TargetType)
+	}
+
+	if query.TargetID != 0 {
+		sqlMain = sqlMain.Where("target_id = ?", query.TargetID)
+	}
+
+	if query.From != nil {
+		sqlMain = sqlMain.Where("created_at >= ?", *query.From)
+	}
+
+	if query.To != nil {
+		sqlMain = sqlMain.Where("created_at < ?", *query.To)
+	}
+
+	if err := sqlMain.Count(&countData).Error; err != nil {
+		log.Errorf("[AuditLogRepository-1] GetAll: %v", err)
+		return nil, 0, 0, err
+	}
+
+	offset := (query.Page - 1) * query.Limit
+	totalPage := int64(math.Ceil(float64(countData) / float64(query.Limit)))
+
+	if err := sqlMain.Order("created_at DESC, id DESC").
+		Limit(int(query.Limit)).
+		Offset(int(offset)).
+		Find(&modelAuditLogs).Error; err != nil {
+		log.Errorf("[AuditLogRepository-2] GetAll: %v", err)
+		return nil, 0, 0, err
+	}
+
+	if len(modelAuditLogs) == 0 {
+		err := errors.New("404")
+		log.Infof("[AuditLogRepository-3] GetAll: %v", err)
+		return nil, 0, 0, err
+	}
+
+	entities := []entity.AuditLogEntity{}
+	for _, val := range modelAuditLogs {
+		entities = append(entities, toAuditLogEntity(val))
+	}
+
+	return entities, countData, totalPage, nil
+}
+
+func toAuditLogEntity(modelAuditLog model.AuditLog) entity.AuditLogEntity {
+	auditLog := entity.AuditLogEntity{
+		ID:        modelAuditLog.ID,
+		Action:    modelAuditLog.Action,
+		IPAddress: modelAuditLog.IPAddress,
+		UserAgent: modelAuditLog.UserAgent,
+		CreatedAt: modelAuditLog.CreatedAt,
+	}
+
+	if modelAuditLog.ActorID != nil {
+		auditLog.ActorID = *modelAuditLog.ActorID
+	}
+
+	if modelAuditLog.TargetType != nil && modelAuditLog.TargetID != nil {
+		auditLog.TargetType = *modelAuditLog.TargetType
+		auditLog.TargetID = *modelAuditLog.TargetID
+	}
+
+	// Both columns are written by Create, so a decode error only drops the
+	// detail and not the entry.
+	if modelAuditLog.Changes != nil {
+		if err := json.Unmarshal([]byte(*modelAuditLog.Changes), &auditLog.Changes); err != nil {
+			log.Errorf("[AuditLogRepository-1] toAuditLogEntity: %v", err)
+		}
+	}
+
+	if modelAuditLog.Metadata != nil {
+		if err := json.Unmarshal([]byte(*modelAuditLog.Metadata), &auditLog.Metadata); err != nil {
+			log.Errorf("[AuditLogRepository-2] toAuditLogEntity: %v", err)
+		}
+	}
+
+	return auditLog
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepositoryInterface {
+	return &auditLogRepository{
+		db: db,
+	}
+}
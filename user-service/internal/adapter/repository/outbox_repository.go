@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/domain/model"
+)
+
+// OutboxRepositoryInterface is mostly used by the relay. Events that
+// announce a change are written by the repository that owns the change,
+// inside its transaction, with insertOutboxEvents; Create is for messages
+// that do not go with any database change.
+type OutboxRepositoryInterface interface {
+	Create(ctx context.Context, aggregateType string, aggregateID int64, events ...entity.OutboxEventEntity) error
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxEventEntity, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, lastError string, retryIn time.Duration) error
+	DeletePublished(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// Create implements OutboxRepositoryInterface.
+func (o *outboxRepository) Create(ctx context.Context, aggregateType string, aggregateID int64, events ...entity.OutboxEventEntity) error {
+	if err := insertOutboxEvents(o.db.WithContext(ctx), aggregateType, aggregateID, events); err != nil {
+		log.Errorf("[OutboxRepository-1] Create: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimPending implements OutboxRepositoryInterface.
+// Only the oldest unpublished event of each aggregate is eligible, so a
+// later event is never published before an earlier one that is still
+// failing. Claimed events are pushed lease into the future, which keeps
+// other relays away until they are marked, or retries them if this relay
+// dies first.
+func (o *outboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxEventEntity, error) {
+	modelEvents := []model.OutboxEvent{}
+
+	err := o.db.WithContext(ctx).Raw(`
+		UPDATE outbox_events SET next_attempt_at = NOW() + ? * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT e.id FROM outbox_events e
+			WHERE e.published_at IS NULL AND e.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events earlier
+				WHERE earlier.aggregate_type = e.aggregate_type
+				AND earlier.aggregate_id = e.aggregate_id
+				AND earlier.published_at IS NULL
+				AND earlier.id < e.id
+			)
+			ORDER BY e.id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, lease.Seconds(), limit).Scan(&modelEvents).Error
+	if err != nil {
+		log.Errorf("[OutboxRepository-1] ClaimPending: %v", err)
+		return nil, err
+	}
+
+	events := make([]entity.OutboxEventEntity, 0, len(modelEvents))
+	for _, modelEvent := range modelEvents {
+		events = append(events, entity.OutboxEventEntity{
+			ID:            modelEvent.ID,
+			AggregateType: modelEvent.AggregateType,
+			AggregateID:   modelEvent.AggregateID,
+			Exchange:      modelEvent.Exchange,
+			RoutingKey:    modelEvent.RoutingKey,
+			Payload:       []byte(modelEvent.Payload),
+			Attempts:      modelEvent.Attempts,
+			CreatedAt:     modelEvent.CreatedAt,
+		})
+	}
+
+	// RETURNING does not keep the subquery order.
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
+
+// MarkPublished implements OutboxRepositoryInterface.
+func (o *outboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	err := o.db.WithContext(ctx).Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"published_at": gorm.Expr("NOW()"),
+		"last_error":   nil,
+	}).Error
+	if err != nil {
+		log.Errorf("[OutboxRepository-1] MarkPublished: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkFailed implements OutboxRepositoryInterface.
+func (o *outboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, retryIn time.Duration) error {
+	err := o.db.WithContext(ctx).Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      lastError,
+		"next_attempt_at": gorm.Expr("NOW() + ? * INTERVAL '1 second'", retryIn.Seconds()),
+	}).Error
+	if err != nil {
+		log.Errorf("[OutboxRepository-1] MarkFailed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeletePublished implements OutboxRepositoryInterface.
+func (o *outboxRepository) DeletePublished(ctx context.Context, olderThan time.Duration) (int64, error) {
+	result := o.db.WithContext(ctx).
+		Where("published_at IS NOT NULL AND published_at < NOW() - ? * INTERVAL '1 second'", olderThan.Seconds()).
+		Delete(&model.OutboxEvent{})
+	if result.Error != nil {
+		log.Errorf("[OutboxRepository-1] DeletePublished: %v", result.Error)
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// insertOutboxEvents stores events for one aggregate with tx, so they are
+// committed or rolled back together with the change they announce.
+func insertOutboxEvents(tx *gorm.DB, aggregateType string, aggregateID int64, events []entity.OutboxEventEntity) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	modelEvents := make([]model.OutboxEvent, 0, len(events))
+	for _, event := range events {
+		modelEvents = append(modelEvents, model.OutboxEvent{
+			AggregateType: aggregateType,
+			AggregateID:   aggregateID,
+			Exchange:      event.Exchange,
+			RoutingKey:    event.RoutingKey,
+			Payload:       string(event.Payload),
+		})
+	}
+
+	return tx.Create(&modelEvents).Error
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepositoryInterface {
+	return &outboxRepository{db: db}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+
+	"user-service/internal/core/domain/model"
+)
+
+type PasswordHistoryRepositoryInterface interface {
+	GetRecentHashes(ctx context.Context, userID int64, limit int) ([]string, error)
+	Add(ctx context.Context, userID int64, hash string, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+// GetRecentHashes implements PasswordHistoryRepositoryInterface.
+func (p *passwordHistoryRepository) GetRecentHashes(ctx context.Context, userID int64, limit int) ([]string, error) {
+	hashes := []string{}
+
+	if err := p.db.Model(&model.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").Limit(limit).Pluck("password_hash", &hashes).Error; err != nil {
+		log.Errorf("[PasswordHistoryRepository-1] GetRecentHashes: %v", err)
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// Add implements PasswordHistoryRepositoryInterface.
+// Only the newest keep entries are retained for the user.
+func (p *passwordHistoryRepository) Add(ctx context.Context, userID int64, hash string, keep int) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model.PasswordHistory{UserID: userID, PasswordHash: hash}).Error; err != nil {
+			log.Errorf("[PasswordHistoryRepository-1] Add: %v", err)
+			return err
+		}
+
+		keepIDs := tx.Model(&model.PasswordHistory{}).Select("id").Where("user_id = ?", userID).
+			Order("created_at DESC, id DESC").Limit(keep)
+		if err := tx.Where("user_id = ? AND id NOT IN (?)", userID, keepIDs).
+			Delete(&model.PasswordHistory{}).Error; err != nil {
+			log.Errorf("[PasswordHistoryRepository-2] Add: %v", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepositoryInterface {
+	return &passwordHistoryRepository{db: db}
+}
@@ -14,9 +14,12 @@ import (
 type RoleRepositoryI interface {
 	GetAll(ctx context.Context, search string) ([]entity.RoleEntity, error)
 	GetByID(ctx context.Context, id int64) (*entity.RoleEntity, error)
-	Create(ctx context.Context, req entity.RoleEntity) error
+	Create(ctx context.Context, req entity.RoleEntity) (int64, error)
 	Delete(ctx context.Context, id int64) error
 	Update(ctx context.Context, req entity.RoleEntity) error
+	AssignRoleToUser(ctx context.Context, userID, roleID int64) error
+	UnassignRoleFromUser(ctx context.Context, userID, roleID int64) error
+	UpdateTwoFactorRequirement(ctx context.Context, id int64, required bool) error
 }
 
 type roleRepository struct {
@@ -24,24 +27,59 @@ type roleRepository struct {
 }
 
 // Create implements RoleRepositoryI.
-func (r *roleRepository) Create(ctx context.Context, req entity.RoleEntity) error {
+func (r *roleRepository) Create(ctx context.Context, req entity.RoleEntity) (int64, error) {
+	var count int64
+	if err := r.db.Model(&model.Role{}).Where("LOWER(name) = LOWER(?)", req.Name).Count(&count).Error; err != nil {
+		log.Errorf("[RoleRepository-1] Create: %v", err)
+		return 0, err
+	}
+
+	if count > 0 {
+		err := errors.New("409")
+		log.Infof("[RoleRepository-2] Create: %v", err)
+		return 0, err
+	}
+
 	modelRole := model.Role{
 		Name: req.Name,
 	}
 
-	if err := r.db.Create(&model.User).Error; err != nil {
-		log.Errorf("[RoleRepository-1] Create: %v", err)
-		return err
+	if err := r.db.Create(&modelRole).Error; err != nil {
+		log.Errorf("[RoleRepository-3] Create: %v", err)
+		return 0, err
 	}
-	return nil
+	return modelRole.ID, nil
 }
 
 // Delete implements RoleRepositoryI.
 func (r *roleRepository) Delete(ctx context.Context, id int64) error {
 	modelRole := model.Role{}
 
-	if err := r.db.Where("id = ?", id).Preload().Error; err != nil {
-		log.Errorf("[RoleRepository-1] Delete: %v", err)
+	if err := r.db.Where("id = ?", id).First(&modelRole).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[RoleRepository-1] Delete: %v", err)
+			return err
+		}
+
+		log.Errorf("[RoleRepository-2] Delete: %v", err)
+		return err
+	}
+
+	var count int64
+	if err := r.db.Table("user_role").Where("role_id = ?", id).Count(&count).Error; err != nil {
+		log.Errorf("[RoleRepository-3] Delete: %v", err)
+		return err
+	}
+
+	if count > 0 {
+		err := errors.New("409")
+		log.Infof("[RoleRepository-4] Delete: %v", err)
+		return err
+	}
+
+	if err := r.db.Delete(&modelRole).Error; err != nil {
+		log.Errorf("[RoleRepository-5] Delete: %v", err)
 		return err
 	}
 
@@ -52,7 +90,7 @@ func (r *roleRepository) Delete(ctx context.Context, id int64) error {
 func (r *roleRepository) GetAll(ctx context.Context, search string) ([]entity.RoleEntity, error) {
 	modelRoles := []model.Role{}
 
-	if err := r.db.Where("name ILIKE ?", "%"+search+"%").Find(&modelRoles).Error; err != nil {
+	if err := r.db.Where("name ILIKE ?", "%"+search+"%").Order("id ASC").Find(&modelRoles).Error; err != nil {
 		log.Errorf("[RoleRepository-1] GetAll: %v", err)
 		return nil, err
 	}
@@ -66,8 +104,9 @@ func (r *roleRepository) GetAll(ctx context.Context, search string) ([]entity.Ro
 	entityRole := []entity.RoleEntity{}
 	for _, role := range modelRoles {
 		entityRole = append(entityRole, entity.RoleEntity{
-			ID:   role.ID,
-			Name: role.Name,
+			ID:               role.ID,
+			Name:             role.Name,
+			RequireTwoFactor: role.RequireTwoFactor,
 		})
 	}
 
@@ -90,15 +129,149 @@ func (r *roleRepository) GetByID(ctx context.Context, id int64) (*entity.RoleEnt
 	}
 
 	return &entity.RoleEntity{
-		ID:   modelRole.ID,
-		Name: modelRole.Name,
+		ID:               modelRole.ID,
+		Name:             modelRole.Name,
+		RequireTwoFactor: modelRole.RequireTwoFactor,
 	}, nil
 
 }
 
 // Update implements RoleRepositoryI.
 func (r *roleRepository) Update(ctx context.Context, req entity.RoleEntity) error {
-	panic("unimplemented")
+	modelRole := model.Role{}
+
+	if err := r.db.Where("id = ?", req.ID).First(&modelRole).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[RoleRepository-1] Update: %v", err)
+			return err
+		}
+
+		log.Errorf("[RoleRepository-2] Update: %v", err)
+		return err
+	}
+
+	var count int64
+	if err := r.db.Model(&model.Role{}).Where("LOWER(name) = LOWER(?) AND id <> ?", req.Name, req.ID).Count(&count).Error; err != nil {
+		log.Errorf("[RoleRepository-3] Update: %v", err)
+		return err
+	}
+
+	if count > 0 {
+		err := errors.New("409")
+		log.Infof("[RoleRepository-4] Update: %v", err)
+		return err
+	}
+
+	modelRole.Name = req.Name
+	if err := r.db.Save(&modelRole).Error; err != nil {
+		log.Errorf("[RoleRepository-5] Update: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// AssignRoleToUser implements RoleRepositoryI.
+func (r *roleRepository) AssignRoleToUser(ctx context.Context, userID, roleID int64) error {
+	modelUser := model.User{}
+	if err := r.db.Where("id = ? AND deleted_at IS NULL", userID).Preload("Roles").First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[RoleRepository-1] AssignRoleToUser: %v", err)
+			return err
+		}
+
+		log.Errorf("[RoleRepository-2] AssignRoleToUser: %v", err)
+		return err
+	}
+
+	modelRole := model.Role{}
+	if err := r.db.Where("id = ?", roleID).First(&modelRole).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[RoleRepository-3] AssignRoleToUser: %v", err)
+			return err
+		}
+
+		log.Errorf("[RoleRepository-4] AssignRoleToUser: %v", err)
+		return err
+	}
+
+	for _, role := range modelUser.Roles {
+		if role.ID == roleID {
+			err := errors.New("409")
+			log.Infof("[RoleRepository-5] AssignRoleToUser: %v", err)
+			return err
+		}
+	}
+
+	if err := r.db.Model(&modelUser).Association("Roles").Append(&modelRole); err != nil {
+		log.Errorf("[RoleRepository-6] AssignRoleToUser: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// UnassignRoleFromUser implements RoleRepositoryI.
+// A user always keeps at least one role, so removing the last one fails with 422.
+func (r *roleRepository) UnassignRoleFromUser(ctx context.Context, userID, roleID int64) error {
+	modelUser := model.User{}
+	if err := r.db.Where("id = ? AND deleted_at IS NULL", userID).Preload("Roles").First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[RoleRepository-1] UnassignRoleFromUser: %v", err)
+			return err
+		}
+
+		log.Errorf("[RoleRepository-2] UnassignRoleFromUser: %v", err)
+		return err
+	}
+
+	var modelRole *model.Role
+	for _, role := range modelUser.Roles {
+		if role.ID == roleID {
+			modelRole = role
+			break
+		}
+	}
+
+	if modelRole == nil {
+		err := errors.New("404")
+		log.Infof("[RoleRepository-3] UnassignRoleFromUser: %v", err)
+		return err
+	}
+
+	if len(modelUser.Roles) <= 1 {
+		err := errors.New("422")
+		log.Infof("[RoleRepository-4] UnassignRoleFromUser: %v", err)
+		return err
+	}
+
+	if err := r.db.Model(&modelUser).Association("Roles").Delete(modelRole); err != nil {
+		log.Errorf("[RoleRepository-5] UnassignRoleFromUser: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateTwoFactorRequirement implements RoleRepositoryI.
+func (r *roleRepository) UpdateTwoFactorRequirement(ctx context.Context, id int64, required bool) error {
+	result := r.db.Model(&model.Role{}).Where("id = ?", id).Update("require_two_factor", required)
+	if result.Error != nil {
+		log.Errorf("[RoleRepository-1] UpdateTwoFactorRequirement: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		err := errors.New("404")
+		log.Infof("[RoleRepository-2] UpdateTwoFactorRequirement: %v", err)
+		return err
+	}
+
+	return nil
 }
 
 func NewRoleRepository(db *gorm.DB) RoleRepositoryI {
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/domain/model"
+)
+
+type TwoFactorRepositoryInterface interface {
+	GetTwoFactor(ctx context.Context, userID int64) (*entity.TwoFactorEntity, error)
+	SaveSecret(ctx context.Context, userID int64, secret string) error
+	Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+	Disable(ctx context.Context, userID int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+// GetTwoFactor implements TwoFactorRepositoryInterface.
+func (t *twoFactorRepository) GetTwoFactor(ctx context.Context, userID int64) (*entity.TwoFactorEntity, error) {
+	modelUser := model.User{}
+
+	if err := t.db.Where("id = ? AND deleted_at IS NULL", userID).First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[TwoFactorRepository-1] GetTwoFactor: %v", err)
+			return nil, err
+		}
+
+		log.Errorf("[TwoFactorRepository-2] GetTwoFactor: %v", err)
+		return nil, err
+	}
+
+	return &entity.TwoFactorEntity{
+		UserID:  modelUser.ID,
+		Email:   modelUser.Email,
+		Secret:  modelUser.TwoFactorSecret,
+		Enabled: modelUser.TwoFactorEnabled,
+	}, nil
+}
+
+// SaveSecret implements TwoFactorRepositoryInterface.
+// The secret stays pending until Enable is called.
+func (t *twoFactorRepository) SaveSecret(ctx context.Context, userID int64, secret string) error {
+	result := t.db.Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL AND two_factor_enabled = false", userID).
+		Update("two_factor_secret", secret)
+	if result.Error != nil {
+		log.Errorf("[TwoFactorRepository-1] SaveSecret: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		err := errors.New("409")
+		log.Infof("[TwoFactorRepository-2] SaveSecret: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Enable implements TwoFactorRepositoryInterface.
+// Existing recovery codes are replaced by the given ones.
+func (t *twoFactorRepository) Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Update("two_factor_enabled", true).Error; err != nil {
+			log.Errorf("[TwoFactorRepository-1] Enable: %v", err)
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			log.Errorf("[TwoFactorRepository-2] Enable: %v", err)
+			return err
+		}
+
+		codes := []model.RecoveryCode{}
+		for _, hash := range recoveryCodeHashes {
+			codes = append(codes, model.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+
+		if err := tx.Create(&codes).Error; err != nil {
+			log.Errorf("[TwoFactorRepository-3] Enable: %v", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Disable implements TwoFactorRepositoryInterface.
+func (t *twoFactorRepository) Disable(ctx context.Context, userID int64) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"two_factor_enabled": false, "two_factor_secret": ""}).Error; err != nil {
+			log.Errorf("[TwoFactorRepository-1] Disable: %v", err)
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			log.Errorf("[TwoFactorRepository-2] Disable: %v", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// UseRecoveryCode implements TwoFactorRepositoryInterface.
+func (t *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	result := t.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.Errorf("[TwoFactorRepository-1] UseRecoveryCode: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		err := errors.New("401")
+		log.Infof("[TwoFactorRepository-2] UseRecoveryCode: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepositoryInterface {
+	return &twoFactorRepository{
+		db: db,
+	}
+}
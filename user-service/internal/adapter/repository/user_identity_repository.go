@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+
+	"user-service/internal/core/domain/entity"
+	"user-service/internal/core/domain/model"
+)
+
+type UserIdentityRepositoryInterface interface {
+	GetUserIDByIdentity(ctx context.Context, provider, subject string) (int64, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.UserEntity, error)
+	LinkIdentity(ctx context.Context, userID int64, identity entity.OAuthIdentityEntity, passwordHash string) error
+	CreateUserWithIdentity(ctx context.Context, identity entity.OAuthIdentityEntity, passwordHash string) (int64, error)
+}
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+// GetUserIDByIdentity implements UserIdentityRepositoryInterface.
+func (u *userIdentityRepository) GetUserIDByIdentity(ctx context.Context, provider, subject string) (int64, error) {
+	modelIdentity := model.UserIdentity{}
+
+	if err := u.db.Where("provider = ? AND subject = ?", provider, subject).First(&modelIdentity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[UserIdentityRepository-1] GetUserIDByIdentity: %v", err)
+			return 0, err
+		}
+
+		log.Errorf("[UserIdentityRepository-2] GetUserIDByIdentity: %v", err)
+		return 0, err
+	}
+
+	return modelIdentity.UserID, nil
+}
+
+// GetUserByEmail implements UserIdentityRepositoryInterface.
+// Unlike UserRepository.GetUserByEmail it also finds unverified users.
+func (u *userIdentityRepository) GetUserByEmail(ctx context.Context, email string) (*entity.UserEntity, error) {
+	modelUser := model.User{}
+
+	if err := u.db.Where("LOWER(email) = LOWER(?) AND deleted_at IS NULL", email).First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[UserIdentityRepository-1] GetUserByEmail: %v", err)
+			return nil, err
+		}
+
+		log.Errorf("[UserIdentityRepository-2] GetUserByEmail: %v", err)
+		return nil, err
+	}
+
+	return &entity.UserEntity{
+		ID:         modelUser.ID,
+		Email:      modelUser.Email,
+		IsVerified: modelUser.IsVerified,
+	}, nil
+}
+
+// LinkIdentity implements UserIdentityRepositoryInterface.
+// A non-empty passwordHash marks the user verified and replaces the
+// password, used when linking to an account that never proved it owns
+// the email.
+func (u *userIdentityRepository) LinkIdentity(ctx context.Context, userID int64, identity entity.OAuthIdentityEntity, passwordHash string) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		modelIdentity := model.UserIdentity{
+			UserID:   userID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}
+
+		if err := tx.Create(&modelIdentity).Error; err != nil {
+			log.Errorf("[UserIdentityRepository-1] LinkIdentity: %v", err)
+			return err
+		}
+
+		if passwordHash == "" {
+			return nil
+		}
+
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"is_verified": true, "password": passwordHash}).Error; err != nil {
+			log.Errorf("[UserIdentityRepository-2] LinkIdentity: %v", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// CreateUserWithIdentity implements UserIdentityRepositoryInterface.
+func (u *userIdentityRepository) CreateUserWithIdentity(ctx context.Context, identity entity.OAuthIdentityEntity, passwordHash string) (int64, error) {
+	modelUser := model.User{}
+
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		modelRole := &model.Role{}
+		if err := tx.Where("name = ?", "Customer").First(&modelRole).Error; err != nil {
+			log.Errorf("[UserIdentityRepository-1] CreateUserWithIdentity: %v", err)
+			return err
+		}
+
+		modelUser = model.User{
+			Name:       identity.Name,
+			Email:      identity.Email,
+			Password:   passwordHash,
+			IsVerified: true,
+			Roles:      []*model.Role{modelRole},
+		}
+
+		if err := tx.Create(&modelUser).Error; err != nil {
+			log.Errorf("[UserIdentityRepository-2] CreateUserWithIdentity: %v", err)
+			return err
+		}
+
+		modelIdentity := model.UserIdentity{
+			UserID:   modelUser.ID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}
+
+		if err := tx.Create(&modelIdentity).Error; err != nil {
+			log.Errorf("[UserIdentityRepository-3] CreateUserWithIdentity: %v", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return modelUser.ID, nil
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepositoryInterface {
+	return &userIdentityRepository{db: db}
+}
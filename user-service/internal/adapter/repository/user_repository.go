@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/labstack/gommon/log"
@@ -14,29 +17,306 @@ import (
 
 type UserRepositoryInterface interface {
 	GetUserByEmail(ctx context.Context, email string) (*entity.UserEntity, error)
-	CreateUserAccount(ctx context.Context, req entity.UserEntity) error
+	GetUnverifiedUserByEmail(ctx context.Context, email string) (*entity.UserEntity, error)
+	CreateUserAccount(ctx context.Context, req entity.UserEntity, events ...entity.OutboxEventEntity) error
 	UpdateUserVerified(ctx context.Context, userID int64) (*entity.UserEntity, error)
-	UpdatePasswordByID(ctx context.Context, req entity.UserEntity) error
+	UpdatePasswordByID(ctx context.Context, req entity.UserEntity, events ...entity.OutboxEventEntity) error
 	GetUserByID(ctx context.Context, userID int64) (*entity.UserEntity, error)
 	UpdateDataUser(ctx context.Context, req entity.UserEntity) error
+	SetPhoneVerified(ctx context.Context, userID int64, phone string) error
+	UpdatePhoto(ctx context.Context, userID int64, photo entity.ProfilePhotoEntity) error
+	EmailExists(ctx context.Context, email string, exceptUserID int64) (bool, error)
+	SetPendingEmail(ctx context.Context, userID int64, email string) error
+	ConfirmPendingEmail(ctx context.Context, userID int64) error
+	GetAllUser(ctx context.Context, query entity.QueryUserEntity) ([]entity.UserEntity, int64, int64, error)
+	GetNearbyUsers(ctx context.Context, query entity.QueryNearbyUserEntity) ([]entity.NearbyUserEntity, error)
+	GetUserDetailByID(ctx context.Context, userID int64) (*entity.UserEntity, error)
+	UpdateSuspendStatus(ctx context.Context, userID int64, isSuspended bool) error
+	SoftDeleteUser(ctx context.Context, userID int64, events ...entity.OutboxEventEntity) error
 }
 
 type userRepository struct {
 	db *gorm.DB
 }
 
+var userOrderColumns = map[string]string{
+	"id":         "users.id",
+	"name":       "users.name",
+	"email":      "users.email",
+	"created_at": "users.created_at",
+}
+
+// GetAllUser implements UserRepositoryInterface.
+func (u *userRepository) GetAllUser(ctx context.Context, query entity.QueryUserEntity) ([]entity.UserEntity, int64, int64, error) {
+	modelUsers := []model.User{}
+	var countData int64
+
+	sqlMain := u.db.Model(&model.User{}).Where("users.deleted_at IS NULL")
+
+	if query.Search != "" {
+		search := "%" + query.Search + "%"
+		sqlMain = sqlMain.Where("(users.name ILIKE ? OR users.email ILIKE ?)", search, search)
+	}
+
+	if query.Name != "" {
+		sqlMain = sqlMain.Where("users.name ILIKE ?", "%"+query.Name+"%")
+	}
+
+	if query.Email != "" {
+		sqlMain = sqlMain.Where("users.email ILIKE ?", "%"+query.Email+"%")
+	}
+
+	if query.IsVerified != nil {
+		sqlMain = sqlMain.Where("users.is_verified = ?", *query.IsVerified)
+	}
+
+	if query.RoleName != "" {
+		subQuery := u.db.Table("user_role").
+			Select("user_role.user_id").
+			Joins("JOIN roles ON roles.id = user_role.role_id").
+			Where("roles.name ILIKE ?", query.RoleName)
+		sqlMain = sqlMain.Where("users.id IN (?)", subQuery)
+	}
+
+	if err := sqlMain.Count(&countData).Error; err != nil {
+		log.Errorf("[UserRepository-1] GetAllUser: %v", err)
+		return nil, 0, 0, err
+	}
+
+	orderColumn, ok := userOrderColumns[query.OrderBy]
+	if !ok {
+		orderColumn = userOrderColumns["created_at"]
+	}
+
+	orderType := "DESC"
+	if strings.EqualFold(query.OrderType, "asc") {
+		orderType = "ASC"
+	}
+
+	offset := (query.Page - 1) * query.Limit
+	totalPage := int64(math.Ceil(float64(countData) / float64(query.Limit)))
+
+	if err := sqlMain.Preload("Roles").
+		Order(fmt.Sprintf("%s %s", orderColumn, orderType)).
+		Limit(int(query.Limit)).
+		Offset(int(offset)).
+		Find(&modelUsers).Error; err != nil {
+		log.Errorf("[UserRepository-2] GetAllUser: %v", err)
+		return nil, 0, 0, err
+	}
+
+	if len(modelUsers) == 0 {
+		err := errors.New("404")
+		log.Infof("[UserRepository-3] GetAllUser: %v", err)
+		return nil, 0, 0, err
+	}
+
+	entities := []entity.UserEntity{}
+	for _, val := range modelUsers {
+		entities = append(entities, toUserEntity(val))
+	}
+
+	return entities, countData, totalPage, nil
+}
+
+// GetNearbyUsers implements UserRepositoryInterface.
+// Results are ordered by distance, closest first. Users without a location
+// are skipped.
+func (u *userRepository) GetNearbyUsers(ctx context.Context, query entity.QueryNearbyUserEntity) ([]entity.NearbyUserEntity, error) {
+	rows := []struct {
+		ID       int64
+		Distance float64
+	}{}
+
+	point := gorm.Expr("ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography", query.Lng, query.Lat)
+
+	sqlMain := u.db.Table("users").
+		Select("users.id, ST_Distance(users.location, ?) AS distance", point).
+		Where("users.deleted_at IS NULL AND users.location IS NOT NULL")
+
+	if query.RadiusMeters > 0 {
+		sqlMain = sqlMain.Where("ST_DWithin(users.location, ?, ?)", point, query.RadiusMeters)
+	}
+
+	if query.RoleName != "" {
+		subQuery := u.db.Table("user_role").
+			Select("user_role.user_id").
+			Joins("JOIN roles ON roles.id = user_role.role_id").
+			Where("roles.name ILIKE ?", query.RoleName)
+		sqlMain = sqlMain.Where("users.id IN (?)", subQuery)
+	}
+
+	if err := sqlMain.Order("distance ASC").Limit(int(query.Limit)).Scan(&rows).Error; err != nil {
+		log.Errorf("[UserRepository-1] GetNearbyUsers: %v", err)
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		err := errors.New("404")
+		log.Infof("[UserRepository-2] GetNearbyUsers: %v", err)
+		return nil, err
+	}
+
+	userIDs := []int64{}
+	for _, row := range rows {
+		userIDs = append(userIDs, row.ID)
+	}
+
+	modelUsers := []model.User{}
+	if err := u.db.Where("id IN ?", userIDs).Preload("Roles").Find(&modelUsers).Error; err != nil {
+		log.Errorf("[UserRepository-3] GetNearbyUsers: %v", err)
+		return nil, err
+	}
+
+	usersByID := map[int64]model.User{}
+	for _, val := range modelUsers {
+		usersByID[val.ID] = val
+	}
+
+	entities := []entity.NearbyUserEntity{}
+	for _, row := range rows {
+		modelUser, ok := usersByID[row.ID]
+		if !ok {
+			continue
+		}
+
+		entities = append(entities, entity.NearbyUserEntity{
+			UserEntity:     toUserEntity(modelUser),
+			DistanceMeters: row.Distance,
+		})
+	}
+
+	return entities, nil
+}
+
+// GetUserDetailByID implements UserRepositoryInterface.
+func (u *userRepository) GetUserDetailByID(ctx context.Context, userID int64) (*entity.UserEntity, error) {
+	modelUser := model.User{}
+
+	if err := u.db.Where("id = ? AND deleted_at IS NULL", userID).Preload("Roles").First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[UserRepository-1] GetUserDetailByID: %v", err)
+			return nil, err
+		}
+
+		log.Errorf("[UserRepository-2] GetUserDetailByID: %v", err)
+		return nil, err
+	}
+
+	entityUser := toUserEntity(modelUser)
+	return &entityUser, nil
+}
+
+// UpdateSuspendStatus implements UserRepositoryInterface.
+func (u *userRepository) UpdateSuspendStatus(ctx context.Context, userID int64, isSuspended bool) error {
+	modelUser := model.User{}
+
+	if err := u.db.Where("id = ? AND deleted_at IS NULL", userID).First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[UserRepository-1] UpdateSuspendStatus: %v", err)
+			return err
+		}
+
+		log.Errorf("[UserRepository-2] UpdateSuspendStatus: %v", err)
+		return err
+	}
+
+	if err := u.db.Model(&modelUser).Update("is_suspended", isSuspended).Error; err != nil {
+		log.Errorf("[UserRepository-3] UpdateSuspendStatus: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SoftDeleteUser implements UserRepositoryInterface.
+// events are stored for the user in the same transaction.
+func (u *userRepository) SoftDeleteUser(ctx context.Context, userID int64, events ...entity.OutboxEventEntity) error {
+	modelUser := model.User{}
+
+	if err := u.db.Where("id = ? AND deleted_at IS NULL", userID).First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[UserRepository-1] SoftDeleteUser: %v", err)
+			return err
+		}
+
+		log.Errorf("[UserRepository-2] SoftDeleteUser: %v", err)
+		return err
+	}
+
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&modelUser).Update("deleted_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return insertOutboxEvents(tx, entity.OutboxAggregateUser, userID, events)
+	})
+	if err != nil {
+		log.Errorf("[UserRepository-3] SoftDeleteUser: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func roleNames(roles []*model.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+func requiresTwoFactor(roles []*model.Role) bool {
+	for _, role := range roles {
+		if role.RequireTwoFactor {
+			return true
+		}
+	}
+	return false
+}
+
+func toUserEntity(modelUser model.User) entity.UserEntity {
+	roleName := ""
+	if len(modelUser.Roles) > 0 {
+		roleName = modelUser.Roles[0].Name
+	}
+
+	return entity.UserEntity{
+		ID:          modelUser.ID,
+		Name:        modelUser.Name,
+		Email:       modelUser.Email,
+		RoleName:    roleName,
+		Roles:       roleNames(modelUser.Roles),
+		Address:     modelUser.Address,
+		Lat:         modelUser.Lat,
+		Lng:         modelUser.Lng,
+		Phone:       modelUser.Phone,
+		Photo:       modelUser.Photo,
+		IsVerified:  modelUser.IsVerified,
+		IsSuspended: modelUser.IsSuspended,
+		CreatedAt:   modelUser.CreatedAt,
+
+		TwoFactorEnabled:  modelUser.TwoFactorEnabled,
+		TwoFactorRequired: requiresTwoFactor(modelUser.Roles),
+		PhoneVerified:     modelUser.PhoneVerified,
+		PendingEmail:      modelUser.PendingEmail,
+		PhotoMedium:       modelUser.PhotoMedium,
+		PhotoThumbnail:    modelUser.PhotoThumbnail,
+		PhotoPath:         modelUser.PhotoPath,
+	}
+}
+
 // UpdateDataUser implements UserRepositoryInterface.
+// Changing the phone number clears phone_verified. Email and photo are not
+// updated here, see SetPendingEmail and UpdatePhoto.
 func (u *userRepository) UpdateDataUser(ctx context.Context, req entity.UserEntity) error {
 	modelUser := model.User{}
-	modelUser.Name = req.Name
-	modelUser.Email = req.Email
-	modelUser.Address = req.Address
-	modelUser.Lat = req.Lat
-	modelUser.Lng = req.Lng
-	modelUser.Phone = req.Phone
-	modelUser.Photo = req.Photo
 
-	if err := u.db.Where("id = ? AND is_verified = true", req.ID).First(&modelUser).Error; err != nil {
+	if err := u.db.Where("id = ? AND is_verified = true AND deleted_at IS NULL", req.ID).First(&modelUser).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			err = errors.New("404")
 			log.Infof("[UserRepository-1] UpdateDataUser: %v", err)
@@ -47,6 +327,16 @@ func (u *userRepository) UpdateDataUser(ctx context.Context, req entity.UserEnti
 		return err
 	}
 
+	if modelUser.Phone != req.Phone {
+		modelUser.PhoneVerified = false
+	}
+
+	modelUser.Name = req.Name
+	modelUser.Address = req.Address
+	modelUser.Lat = req.Lat
+	modelUser.Lng = req.Lng
+	modelUser.Phone = req.Phone
+
 	if err := u.db.Save(&modelUser).Error; err != nil {
 		log.Errorf("[UserRepository-3] UpdateDataUser: %v", err)
 		return err
@@ -55,11 +345,125 @@ func (u *userRepository) UpdateDataUser(ctx context.Context, req entity.UserEnti
 	return nil
 }
 
+// UpdatePhoto implements UserRepositoryInterface.
+func (u *userRepository) UpdatePhoto(ctx context.Context, userID int64, photo entity.ProfilePhotoEntity) error {
+	result := u.db.Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"photo":           photo.URL,
+			"photo_medium":    photo.MediumURL,
+			"photo_thumbnail": photo.ThumbnailURL,
+			"photo_path":      photo.Path,
+		})
+	if result.Error != nil {
+		log.Errorf("[UserRepository-1] UpdatePhoto: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		err := errors.New("404")
+		log.Infof("[UserRepository-2] UpdatePhoto: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetPhoneVerified implements UserRepositoryInterface.
+// phone must still be the user's number, so a code sent to an old number
+// cannot verify a new one.
+func (u *userRepository) SetPhoneVerified(ctx context.Context, userID int64, phone string) error {
+	result := u.db.Model(&model.User{}).
+		Where("id = ? AND phone = ? AND deleted_at IS NULL", userID, phone).
+		Update("phone_verified", true)
+	if result.Error != nil {
+		log.Errorf("[UserRepository-1] SetPhoneVerified: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		err := errors.New("404")
+		log.Infof("[UserRepository-2] SetPhoneVerified: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// EmailExists implements UserRepositoryInterface.
+// Deleted accounts are included because they still hold the unique email.
+func (u *userRepository) EmailExists(ctx context.Context, email string, exceptUserID int64) (bool, error) {
+	var count int64
+	if err := u.db.Model(&model.User{}).Where("LOWER(email) = LOWER(?) AND id <> ?", email, exceptUserID).Count(&count).Error; err != nil {
+		log.Errorf("[UserRepository-1] EmailExists: %v", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// SetPendingEmail implements UserRepositoryInterface.
+func (u *userRepository) SetPendingEmail(ctx context.Context, userID int64, email string) error {
+	result := u.db.Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Update("pending_email", email)
+	if result.Error != nil {
+		log.Errorf("[UserRepository-1] SetPendingEmail: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		err := errors.New("404")
+		log.Infof("[UserRepository-2] SetPendingEmail: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmPendingEmail implements UserRepositoryInterface.
+// It returns 409 if the address was taken since the change was requested.
+func (u *userRepository) ConfirmPendingEmail(ctx context.Context, userID int64) error {
+	modelUser := model.User{}
+
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND deleted_at IS NULL", userID).First(&modelUser).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("404")
+			}
+			return err
+		}
+
+		if modelUser.PendingEmail == "" {
+			return errors.New("404")
+		}
+
+		var count int64
+		if err := tx.Model(&model.User{}).Where("LOWER(email) = LOWER(?) AND id <> ?", modelUser.PendingEmail, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("409")
+		}
+
+		return tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"email":         modelUser.PendingEmail,
+			"pending_email": gorm.Expr("NULL"),
+		}).Error
+	})
+	if err != nil {
+		log.Errorf("[UserRepository-1] ConfirmPendingEmail: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 // GetUserByID implements UserRepositoryInterface.
 func (u *userRepository) GetUserByID(ctx context.Context, userID int64) (*entity.UserEntity, error) {
 	modelUser := model.User{}
 
-	if err := u.db.Where("id = ? AND is_verified = ?", userID, true).Preload("Roles").First(&modelUser).Error; err != nil {
+	if err := u.db.Where("id = ? AND is_verified = ? AND deleted_at IS NULL", userID, true).Preload("Roles").First(&modelUser).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			err = errors.New("404")
 			log.Infof("[UserRepository-1] GetUserByID: %v", err)
@@ -71,21 +475,33 @@ func (u *userRepository) GetUserByID(ctx context.Context, userID int64) (*entity
 	}
 
 	return &entity.UserEntity{
-		ID:       modelUser.ID,
-		Email:    modelUser.Email,
-		Name:     modelUser.Name,
-		RoleName: modelUser.Roles[0].Name,
-		Address:  modelUser.Address,
-		Lat:      modelUser.Lat,
-		Lng:      modelUser.Lng,
-		Phone:    modelUser.Phone,
-		Photo:    modelUser.Photo,
+		ID:          modelUser.ID,
+		Email:       modelUser.Email,
+		Name:        modelUser.Name,
+		Password:    modelUser.Password,
+		RoleName:    modelUser.Roles[0].Name,
+		Roles:       roleNames(modelUser.Roles),
+		Address:     modelUser.Address,
+		Lat:         modelUser.Lat,
+		Lng:         modelUser.Lng,
+		Phone:       modelUser.Phone,
+		Photo:       modelUser.Photo,
+		IsSuspended: modelUser.IsSuspended,
+
+		TwoFactorEnabled:  modelUser.TwoFactorEnabled,
+		TwoFactorRequired: requiresTwoFactor(modelUser.Roles),
+		PhoneVerified:     modelUser.PhoneVerified,
+		PendingEmail:      modelUser.PendingEmail,
+		PhotoMedium:       modelUser.PhotoMedium,
+		PhotoThumbnail:    modelUser.PhotoThumbnail,
+		PhotoPath:         modelUser.PhotoPath,
 	}, nil
 
 }
 
 // UpdatePasswordByID implements UserRepositoryInterface.
-func (u *userRepository) UpdatePasswordByID(ctx context.Context, req entity.UserEntity) error {
+// events are stored for the user in the same transaction.
+func (u *userRepository) UpdatePasswordByID(ctx context.Context, req entity.UserEntity, events ...entity.OutboxEventEntity) error {
 	modelUser := model.User{}
 
 	if err := u.db.Where("id = ?", req.ID).First(&modelUser).Error; err != nil {
@@ -100,7 +516,14 @@ func (u *userRepository) UpdatePasswordByID(ctx context.Context, req entity.User
 	}
 
 	modelUser.Password = req.Password
-	if err := u.db.Save(&modelUser).Error; err != nil {
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&modelUser).Error; err != nil {
+			return err
+		}
+
+		return insertOutboxEvents(tx, entity.OutboxAggregateUser, modelUser.ID, events)
+	})
+	if err != nil {
 		log.Errorf("[UserRepository-3] UpdatePasswordByID: %v", err)
 		return err
 	}
@@ -129,48 +552,67 @@ func (u *userRepository) UpdateUserVerified(ctx context.Context, userID int64) (
 
 	return &entity.UserEntity{
 		ID:         modelUser.ID,
+		Name:       modelUser.Name,
 		Email:      modelUser.Email,
 		Password:   modelUser.Password,
 		RoleName:   modelUser.Roles[0].Name,
+		Roles:      roleNames(modelUser.Roles),
 		Address:    modelUser.Address,
 		Lat:        modelUser.Lat,
 		Lng:        modelUser.Lng,
 		Phone:      modelUser.Phone,
 		Photo:      modelUser.Photo,
 		IsVerified: modelUser.IsVerified,
+
+		TwoFactorEnabled:  modelUser.TwoFactorEnabled,
+		TwoFactorRequired: requiresTwoFactor(modelUser.Roles),
+		PhoneVerified:     modelUser.PhoneVerified,
+		PendingEmail:      modelUser.PendingEmail,
+		PhotoMedium:       modelUser.PhotoMedium,
+		PhotoThumbnail:    modelUser.PhotoThumbnail,
+		PhotoPath:         modelUser.PhotoPath,
 	}, nil
 }
 
-func (u *userRepository) CreateUserAccount(ctx context.Context, req entity.UserEntity) error {
+// CreateUserAccount implements UserRepositoryInterface.
+// The user, its verification token and events are stored in one
+// transaction, with the new user as the aggregate of every event.
+func (u *userRepository) CreateUserAccount(ctx context.Context, req entity.UserEntity, events ...entity.OutboxEventEntity) error {
 	modelRole := &model.Role{}
 	if err := u.db.Where("name = ?", "Customer").First(&modelRole).Error; err != nil {
 		log.Errorf("[UserRepository-1] CreateUserAccount: %v", err)
 		return err
 	}
 
-	modelUser := model.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: req.Password,
-		Roles:    []*model.Role{modelRole},
-	}
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		modelUser := model.User{
+			Name:     req.Name,
+			Email:    req.Email,
+			Password: req.Password,
+			Roles:    []*model.Role{modelRole},
+		}
 
-	if err := u.db.Create(&modelUser).Error; err != nil {
-		log.Errorf("[UserRepository-2] CreateUserAccount: %v", err)
-		return err
-	}
+		if err := tx.Create(&modelUser).Error; err != nil {
+			return err
+		}
 
-	currentTime := time.Now()
-	modelVerify := model.VerificationToken{
-		UserID:    modelUser.ID,
-		Token:     req.Token,
-		TokenType: "email_verification",
-		ExpiresAt: currentTime.Add(1 * time.Hour),
-		User:      modelUser,
-	}
+		currentTime := time.Now()
+		modelVerify := model.VerificationToken{
+			UserID:    modelUser.ID,
+			Token:     req.Token,
+			TokenType: entity.TokenTypeEmailVerification,
+			ExpiresAt: currentTime.Add(1 * time.Hour),
+			User:      modelUser,
+		}
 
-	if err := u.db.Create(&modelVerify).Error; err != nil {
-		log.Errorf("[UserRepository-3] CreateUserAccount: %v", err)
+		if err := tx.Create(&modelVerify).Error; err != nil {
+			return err
+		}
+
+		return insertOutboxEvents(tx, entity.OutboxAggregateUser, modelUser.ID, events)
+	})
+	if err != nil {
+		log.Errorf("[UserRepository-2] CreateUserAccount: %v", err)
 		return err
 	}
 
@@ -180,7 +622,7 @@ func (u *userRepository) CreateUserAccount(ctx context.Context, req entity.UserE
 func (u *userRepository) GetUserByEmail(ctx context.Context, email string) (*entity.UserEntity, error) {
 	modelUser := model.User{}
 
-	if err := u.db.Where("email = ? AND is_verified = ?", email, true).
+	if err := u.db.Where("LOWER(email) = LOWER(?) AND is_verified = ? AND deleted_at IS NULL", email, true).
 		Preload("Roles").First(&modelUser).Error; err != nil {
 
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -194,21 +636,55 @@ func (u *userRepository) GetUserByEmail(ctx context.Context, email string) (*ent
 	}
 
 	entityUser := entity.UserEntity{
-		ID:         modelUser.ID,
-		Email:      modelUser.Email,
-		Password:   modelUser.Password,
-		RoleName:   modelUser.Roles[0].Name,
-		Address:    modelUser.Address,
-		Lat:        modelUser.Lat,
-		Lng:        modelUser.Lng,
-		Phone:      modelUser.Phone,
-		Photo:      modelUser.Photo,
-		IsVerified: modelUser.IsVerified,
+		ID:          modelUser.ID,
+		Name:        modelUser.Name,
+		Email:       modelUser.Email,
+		Password:    modelUser.Password,
+		RoleName:    modelUser.Roles[0].Name,
+		Roles:       roleNames(modelUser.Roles),
+		Address:     modelUser.Address,
+		Lat:         modelUser.Lat,
+		Lng:         modelUser.Lng,
+		Phone:       modelUser.Phone,
+		Photo:       modelUser.Photo,
+		IsVerified:  modelUser.IsVerified,
+		IsSuspended: modelUser.IsSuspended,
+
+		TwoFactorEnabled:  modelUser.TwoFactorEnabled,
+		TwoFactorRequired: requiresTwoFactor(modelUser.Roles),
+		PhoneVerified:     modelUser.PhoneVerified,
+		PendingEmail:      modelUser.PendingEmail,
+		PhotoMedium:       modelUser.PhotoMedium,
+		PhotoThumbnail:    modelUser.PhotoThumbnail,
+		PhotoPath:         modelUser.PhotoPath,
 	}
 
 	return &entityUser, nil
 }
 
+// GetUnverifiedUserByEmail implements UserRepositoryInterface.
+func (u *userRepository) GetUnverifiedUserByEmail(ctx context.Context, email string) (*entity.UserEntity, error) {
+	modelUser := model.User{}
+
+	if err := u.db.Where("LOWER(email) = LOWER(?) AND is_verified = ? AND deleted_at IS NULL", email, false).
+		First(&modelUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[UserRepository-1] GetUnverifiedUserByEmail: %v", err)
+			return nil, err
+		}
+
+		log.Errorf("[UserRepository-2] GetUnverifiedUserByEmail: %v", err)
+		return nil, err
+	}
+
+	return &entity.UserEntity{
+		ID:    modelUser.ID,
+		Name:  modelUser.Name,
+		Email: modelUser.Email,
+	}, nil
+}
+
 func NewUserRepository(db *gorm.DB) UserRepositoryInterface {
 	return &userRepository{db: db}
 }
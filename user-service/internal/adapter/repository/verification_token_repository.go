@@ -13,8 +13,11 @@ import (
 )
 
 type VerificationTokenRepositoryInterface interface {
-	CreateVerificationToken(ctx context.Context, req entity.VerificationTokenEntity) error
+	CreateVerificationToken(ctx context.Context, req entity.VerificationTokenEntity, events ...entity.OutboxEventEntity) error
+	CreateVerificationTokens(ctx context.Context, reqs []entity.VerificationTokenEntity, events ...entity.OutboxEventEntity) error
 	GetDataByToken(ctx context.Context, token string) (*entity.VerificationTokenEntity, error)
+	ConsumeToken(ctx context.Context, token, tokenType string, siblingTypes ...string) (*entity.VerificationTokenEntity, error)
+	GetLatestToken(ctx context.Context, userID int64, tokenType string) (*entity.VerificationTokenEntity, error)
 }
 
 type verificationTokenRepository struct {
@@ -25,7 +28,7 @@ type verificationTokenRepository struct {
 func (v *verificationTokenRepository) GetDataByToken(ctx context.Context, token string) (*entity.VerificationTokenEntity, error) {
 	modelToken := model.VerificationToken{}
 
-	if err := v.db.Where("token = ?", token).First(&modelToken).Error; err != nil {
+	if err := v.db.Where("token = ? AND deleted_at IS NULL", token).Order("id DESC").First(&modelToken).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			err = errors.New("404")
 			log.Errorf("[VerificationTokenRepository-1] GetDataByToken: %v", err)
@@ -36,35 +39,145 @@ func (v *verificationTokenRepository) GetDataByToken(ctx context.Context, token
 	}
 
 	currentTime := time.Now()
-	if currentTime.After(modelToken.ExpiresAt) {
+	if currentTime.After(modelToken.ExpiresAt) || modelToken.UsedAt != nil {
 		err := errors.New("401")
 		log.Errorf("[VerificationTokenRepository-3] GetDataByToken: %v", err)
 		return nil, err
 	}
 
-	return &entity.VerificationTokenEntity{
-		ID:        modelToken.ID,
-		UserID:    modelToken.UserID,
-		Token:     token,
-		TokenType: modelToken.TokenType,
-		ExpiresAt: modelToken.ExpiresAt,
-	}, nil
+	return toVerificationTokenEntity(modelToken), nil
 }
 
-// CreateVerificationToken implements VerificationTokenRepositoryInterface.
-func (v *verificationTokenRepository) CreateVerificationToken(ctx context.Context, req entity.VerificationTokenEntity) error {
-	modelVerificationToken := model.VerificationToken{
-		UserID:    req.UserID,
-		Token:     req.Token,
-		TokenType: req.TokenType,
+// ConsumeToken implements VerificationTokenRepositoryInterface.
+// The token is marked used with a conditional update, so two concurrent
+// requests with the same link cannot both succeed. The user's unused tokens
+// of siblingTypes, sent together with this one, are used up in the same
+// transaction.
+func (v *verificationTokenRepository) ConsumeToken(ctx context.Context, token, tokenType string, siblingTypes ...string) (*entity.VerificationTokenEntity, error) {
+	now := time.Now()
+	modelToken := model.VerificationToken{}
+
+	err := v.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.VerificationToken{}).
+			Where("token = ? AND token_type = ? AND used_at IS NULL AND deleted_at IS NULL AND expires_at > ?", token, tokenType, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Codes can repeat, so older used or replaced rows may share the
+		// hash; the newest one is the row that was just consumed.
+		if err := tx.Where("token = ? AND token_type = ?", token, tokenType).
+			Order("id DESC").First(&modelToken).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("404")
+			}
+			return err
+		}
+
+		// Expired, already used or replaced by a newer token.
+		if result.RowsAffected == 0 {
+			return errors.New("401")
+		}
+
+		if len(siblingTypes) == 0 {
+			return nil
+		}
+
+		return tx.Model(&model.VerificationToken{}).
+			Where("user_id = ? AND token_type IN ? AND used_at IS NULL", modelToken.UserID, siblingTypes).
+			Update("used_at", now).Error
+	})
+	if err != nil {
+		if err.Error() == "401" || err.Error() == "404" {
+			log.Infof("[VerificationTokenRepository-1] ConsumeToken: %v", err)
+			return nil, err
+		}
+		log.Errorf("[VerificationTokenRepository-2] ConsumeToken: %v", err)
+		return nil, err
 	}
 
-	if err := v.db.Create(&modelVerificationToken).Error; err != nil {
-		log.Errorf("[VerificationTokenRepository-1] CreateVerificationToken: %v", err)
-		return err
+	return toVerificationTokenEntity(modelToken), nil
+}
+
+// GetLatestToken implements VerificationTokenRepositoryInterface.
+func (v *verificationTokenRepository) GetLatestToken(ctx context.Context, userID int64, tokenType string) (*entity.VerificationTokenEntity, error) {
+	modelToken := model.VerificationToken{}
+
+	if err := v.db.Where("user_id = ? AND token_type = ?", userID, tokenType).
+		Order("created_at DESC").First(&modelToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("404")
+			log.Infof("[VerificationTokenRepository-1] GetLatestToken: %v", err)
+			return nil, err
+		}
+		log.Errorf("[VerificationTokenRepository-2] GetLatestToken: %v", err)
+		return nil, err
+	}
+
+	return toVerificationTokenEntity(modelToken), nil
+}
+
+// CreateVerificationToken implements VerificationTokenRepositoryInterface.
+// Older unused tokens of the same type are invalidated so only the most
+// recent link works. events, usually the message carrying the token, are
+// stored for the user in the same transaction.
+func (v *verificationTokenRepository) CreateVerificationToken(ctx context.Context, req entity.VerificationTokenEntity, events ...entity.OutboxEventEntity) error {
+	return v.CreateVerificationTokens(ctx, []entity.VerificationTokenEntity{req}, events...)
+}
+
+// CreateVerificationTokens implements VerificationTokenRepositoryInterface.
+// It stores several tokens of one user, sent in the same message, in one
+// transaction, each replacing the older unused tokens of its type.
+func (v *verificationTokenRepository) CreateVerificationTokens(ctx context.Context, reqs []entity.VerificationTokenEntity, events ...entity.OutboxEventEntity) error {
+	if len(reqs) == 0 {
+		return nil
 	}
 
-	return nil
+	return v.db.Transaction(func(tx *gorm.DB) error {
+		for _, req := range reqs {
+			if req.ExpiresAt.IsZero() {
+				req.ExpiresAt = time.Now().Add(1 * time.Hour)
+			}
+
+			if err := tx.Model(&model.VerificationToken{}).
+				Where("user_id = ? AND token_type = ? AND used_at IS NULL AND deleted_at IS NULL", req.UserID, req.TokenType).
+				Update("deleted_at", time.Now()).Error; err != nil {
+				log.Errorf("[VerificationTokenRepository-1] CreateVerificationTokens: %v", err)
+				return err
+			}
+
+			modelVerificationToken := model.VerificationToken{
+				UserID:    req.UserID,
+				Token:     req.Token,
+				TokenType: req.TokenType,
+				ExpiresAt: req.ExpiresAt,
+			}
+			if err := tx.Create(&modelVerificationToken).Error; err != nil {
+				log.Errorf("[VerificationTokenRepository-2] CreateVerificationTokens: %v", err)
+				return err
+			}
+		}
+
+		if err := insertOutboxEvents(tx, entity.OutboxAggregateUser, reqs[0].UserID, events); err != nil {
+			log.Errorf("[VerificationTokenRepository-3] CreateVerificationTokens: %v", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func toVerificationTokenEntity(modelToken model.VerificationToken) *entity.VerificationTokenEntity {
+	return &entity.VerificationTokenEntity{
+		ID:        modelToken.ID,
+		UserID:    modelToken.UserID,
+		Token:     modelToken.Token,
+		TokenType: modelToken.TokenType,
+		ExpiresAt: modelToken.ExpiresAt,
+		UsedAt:    modelToken.UsedAt,
+		CreatedAt: modelToken.CreatedAt,
+	}
 }
 
 func NewVerificationTokenRepository(db *gorm.DB) VerificationTokenRepositoryInterface {
@@ -0,0 +1,348 @@
+// Package conformance checks that a storage backend behaves the way
+// StorageInterface promises. The same checks run against every backend, so a
+// new one can be trusted once it passes them.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"user-service/internal/adapter/storage"
+)
+
+type Result struct {
+	Name string
+	Err  error
+}
+
+type check struct {
+	name string
+	run  func(ctx context.Context, s *suite) error
+}
+
+type suite struct {
+	storage storage.StorageInterface
+	client  *http.Client
+	text    string
+	binary  string
+	upload  string
+}
+
+var checks = []check{
+	{"upload returns a url", checkUpload},
+	{"stat reports size and content type", checkStat},
+	{"upload replaces an existing object", checkOverwrite},
+	{"signed url serves the object", checkSignedURL},
+	{"binary content round trips", checkBinary},
+	{"download returns the object", checkDownload},
+	{"signed upload stores the object", checkSignedUpload},
+	{"signed url expires", checkSignedURLExpiry},
+	{"stat of a missing object returns ErrNotFound", checkStatMissing},
+	{"download of a missing object returns ErrNotFound", checkDownloadMissing},
+	{"delete removes the object", checkDelete},
+	{"delete of a missing object succeeds", checkDeleteMissing},
+}
+
+// Run uploads, inspects and deletes a few objects under prefix, which should
+// be unique per run. client fetches signed URLs; for backends that serve
+// their own files see HandlerTransport. Checks run in order and later ones
+// rely on earlier ones, so only the first failure is meaningful.
+func Run(ctx context.Context, s storage.StorageInterface, client *http.Client, prefix string) []Result {
+	st := &suite{
+		storage: s,
+		client:  client,
+		text:    prefix + "/hello.txt",
+		binary:  prefix + "/random.bin",
+		upload:  prefix + "/signed-upload.txt",
+	}
+
+	results := []Result{}
+	for _, c := range checks {
+		results = append(results, Result{Name: c.name, Err: c.run(ctx, st)})
+	}
+
+	// Leave nothing behind, whatever failed.
+	_ = s.DeleteFiles(ctx, st.text, st.binary, st.upload)
+
+	return results
+}
+
+// HandlerTransport sends requests straight to h, so signed URLs of a
+// storage.Server can be fetched without starting the HTTP server.
+func HandlerTransport(h http.Handler) http.RoundTripper {
+	return handlerTransport{handler: h}
+}
+
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, req)
+	return recorder.Result(), nil
+}
+
+const (
+	textContent = "hello conformance"
+	textUpdated = "hello again, conformance"
+)
+
+func checkUpload(ctx context.Context, s *suite) error {
+	url, err := s.storage.UploadFile(ctx, s.text, bytes.NewReader([]byte(textContent)), "text/plain")
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		return errors.New("empty url")
+	}
+	return nil
+}
+
+func checkStat(ctx context.Context, s *suite) error {
+	info, err := s.storage.Stat(ctx, s.text)
+	if err != nil {
+		return err
+	}
+
+	if info.Size != int64(len(textContent)) {
+		return fmt.Errorf("size is %d, want %d", info.Size, len(textContent))
+	}
+
+	mediaType, _, err := mime.ParseMediaType(info.ContentType)
+	if err != nil || mediaType != "text/plain" {
+		return fmt.Errorf("content type is %q, want text/plain", info.ContentType)
+	}
+
+	return nil
+}
+
+func checkOverwrite(ctx context.Context, s *suite) error {
+	if _, err := s.storage.UploadFile(ctx, s.text, bytes.NewReader([]byte(textUpdated)), "text/plain"); err != nil {
+		return err
+	}
+
+	info, err := s.storage.Stat(ctx, s.text)
+	if err != nil {
+		return err
+	}
+
+	if info.Size != int64(len(textUpdated)) {
+		return fmt.Errorf("size is %d after overwrite, want %d", info.Size, len(textUpdated))
+	}
+
+	return nil
+}
+
+func checkSignedURL(ctx context.Context, s *suite) error {
+	return s.fetchSigned(ctx, s.text, []byte(textUpdated))
+}
+
+func checkBinary(ctx context.Context, s *suite) error {
+	data := make([]byte, 64*1024)
+	if _, err := rand.Read(data); err != nil {
+		return err
+	}
+
+	if _, err := s.storage.UploadFile(ctx, s.binary, bytes.NewReader(data), "application/octet-stream"); err != nil {
+		return err
+	}
+
+	return s.fetchSigned(ctx, s.binary, data)
+}
+
+func checkDownload(ctx context.Context, s *suite) error {
+	reader, err := s.storage.Download(ctx, s.text)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if string(body) != textUpdated {
+		return fmt.Errorf("download returned %q, want %q", body, textUpdated)
+	}
+
+	return nil
+}
+
+// checkSignedUpload uploads the way a client would, following the target
+// instead of calling UploadFile.
+func checkSignedUpload(ctx context.Context, s *suite) error {
+	target, err := s.storage.SignedUpload(ctx, s.upload, "text/plain", 1024, time.Minute)
+	if err != nil {
+		return err
+	}
+
+	status, err := s.send(ctx, target, []byte(textContent))
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("signed upload answered %d", status)
+	}
+
+	info, err := s.storage.Stat(ctx, s.upload)
+	if err != nil {
+		return err
+	}
+	if info.Size != int64(len(textContent)) {
+		return fmt.Errorf("size is %d after signed upload, want %d", info.Size, len(textContent))
+	}
+
+	return nil
+}
+
+func checkSignedURLExpiry(ctx context.Context, s *suite) error {
+	url, err := s.storage.SignedURL(ctx, s.text, time.Second)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(2 * time.Second)
+
+	status, _, err := s.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	if status < 400 {
+		return fmt.Errorf("expired url answered %d", status)
+	}
+
+	return nil
+}
+
+func checkStatMissing(ctx context.Context, s *suite) error {
+	_, err := s.storage.Stat(ctx, s.text+".missing")
+	if !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func checkDownloadMissing(ctx context.Context, s *suite) error {
+	reader, err := s.storage.Download(ctx, s.text+".missing")
+	if err == nil {
+		reader.Close()
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("got %v, want ErrNotFound", err)
+	}
+	return nil
+}
+
+func checkDelete(ctx context.Context, s *suite) error {
+	if err := s.storage.DeleteFiles(ctx, s.text, s.binary, s.upload); err != nil {
+		return err
+	}
+
+	for _, path := range []string{s.text, s.binary, s.upload} {
+		if _, err := s.storage.Stat(ctx, path); !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("stat of deleted %s got %v, want ErrNotFound", path, err)
+		}
+	}
+
+	return nil
+}
+
+func checkDeleteMissing(ctx context.Context, s *suite) error {
+	return s.storage.DeleteFiles(ctx, s.text+".missing")
+}
+
+func (s *suite) fetchSigned(ctx context.Context, path string, want []byte) error {
+	url, err := s.storage.SignedURL(ctx, path, time.Minute)
+	if err != nil {
+		return err
+	}
+
+	status, body, err := s.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("signed url answered %d", status)
+	}
+	if !bytes.Equal(body, want) {
+		return fmt.Errorf("signed url returned %d bytes that differ from the upload", len(body))
+	}
+
+	return nil
+}
+
+func (s *suite) get(ctx context.Context, url string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// send uploads data to target, as a multipart form for POST and as the raw
+// body otherwise.
+func (s *suite) send(ctx context.Context, target *storage.UploadTarget, data []byte) (int, error) {
+	body := &bytes.Buffer{}
+	contentType := ""
+
+	if target.Method == http.MethodPost {
+		form := multipart.NewWriter(body)
+		for key, value := range target.Fields {
+			if err := form.WriteField(key, value); err != nil {
+				return 0, err
+			}
+		}
+
+		part, err := form.CreateFormFile("file", "upload")
+		if err != nil {
+			return 0, err
+		}
+		if _, err = part.Write(data); err != nil {
+			return 0, err
+		}
+		if err = form.Close(); err != nil {
+			return 0, err
+		}
+		contentType = form.FormDataContentType()
+	} else {
+		body.Write(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, target.Method, target.URL, body)
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range target.Headers {
+		req.Header.Set(key, value)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
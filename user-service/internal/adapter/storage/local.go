@@ -0,0 +1,349 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/config"
+
+	"github.com/labstack/gommon/log"
+)
+
+// localPublicPrefix marks paths that are served without a signature, the
+// same way profile photos live in a public bucket on the other backends.
+const localPublicPrefix = "public/"
+
+// localStruct keeps objects as plain files under dir and serves them itself,
+// for development and tests without network access. The content type is
+// not stored, Stat derives it from the extension or the file contents.
+type localStruct struct {
+	dir        string
+	publicURL  string
+	prefix     string
+	signingKey []byte
+}
+
+func newLocal(cfg config.LocalStorage) (StorageInterface, error) {
+	if cfg.SigningKey == "" {
+		return nil, errors.New("LOCAL_STORAGE_SIGNING_KEY is required for the local storage driver")
+	}
+
+	publicURL, err := url.Parse(strings.TrimRight(cfg.PublicURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Files are served under this path next to the API routes, so it
+	// cannot be the root.
+	if publicURL.Path == "" {
+		return nil, errors.New("LOCAL_STORAGE_URL must have a path, e.g. http://localhost:8080/storage")
+	}
+
+	if err = os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &localStruct{
+		dir:        cfg.Dir,
+		publicURL:  publicURL.String(),
+		prefix:     publicURL.Path,
+		signingKey: []byte(cfg.SigningKey),
+	}, nil
+}
+
+func (l *localStruct) UploadFile(ctx context.Context, path string, file io.Reader, contentType string) (string, error) {
+	fullPath, err := l.resolve(path)
+	if err != nil {
+		log.Errorf("[localStruct-1] UploadFile: %v", err)
+		return "", err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		log.Errorf("[localStruct-2] UploadFile: %v", err)
+		return "", err
+	}
+
+	// Write next to the target and rename, so readers never see a partial
+	// file.
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		log.Errorf("[localStruct-3] UploadFile: %v", err)
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, file); err != nil {
+		tmp.Close()
+		log.Errorf("[localStruct-4] UploadFile: %v", err)
+		return "", err
+	}
+
+	if err = tmp.Close(); err != nil {
+		log.Errorf("[localStruct-5] UploadFile: %v", err)
+		return "", err
+	}
+
+	if err = os.Rename(tmp.Name(), fullPath); err != nil {
+		log.Errorf("[localStruct-6] UploadFile: %v", err)
+		return "", err
+	}
+
+	return l.publicURL + "/" + escapePath(cleanPath(path)), nil
+}
+
+func (l *localStruct) DeleteFiles(ctx context.Context, paths ...string) error {
+	for _, path := range paths {
+		fullPath, err := l.resolve(path)
+		if err != nil {
+			log.Errorf("[localStruct-1] DeleteFiles: %v", err)
+			return err
+		}
+
+		if err = os.Remove(fullPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Errorf("[localStruct-2] DeleteFiles: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *localStruct) SignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	if _, err := l.resolve(path); err != nil {
+		log.Errorf("[localStruct-1] SignedURL: %v", err)
+		return "", err
+	}
+	path = cleanPath(path)
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", l.sign(http.MethodGet, path, expires))
+
+	return l.publicURL + "/" + escapePath(path) + "?" + query.Encode(), nil
+}
+
+// SignedUpload returns a URL that ServeHTTP accepts for PUTs of at most
+// maxSize bytes with the given content type. Like presigned URLs on the
+// other backends it can be replayed until it expires, each PUT replacing
+// the object.
+func (l *localStruct) SignedUpload(ctx context.Context, path, contentType string, maxSize int64, expiry time.Duration) (*UploadTarget, error) {
+	if _, err := l.resolve(path); err != nil {
+		log.Errorf("[localStruct-1] SignedUpload: %v", err)
+		return nil, err
+	}
+	path = cleanPath(path)
+
+	expiresAt := time.Now().Add(expiry)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	size := strconv.FormatInt(maxSize, 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("content_type", contentType)
+	query.Set("max_size", size)
+	query.Set("signature", l.sign(http.MethodPut, path, expires, contentType, size))
+
+	return &UploadTarget{
+		Method:    http.MethodPut,
+		URL:       l.publicURL + "/" + escapePath(path) + "?" + query.Encode(),
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (l *localStruct) Stat(ctx context.Context, path string) (*ObjectInfo, error) {
+	fullPath, err := l.resolve(path)
+	if err != nil {
+		log.Errorf("[localStruct-1] Stat: %v", err)
+		return nil, err
+	}
+
+	fileInfo, err := os.Stat(fullPath)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && fileInfo.IsDir()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Errorf("[localStruct-2] Stat: %v", err)
+		return nil, err
+	}
+
+	contentType, err := detectContentType(fullPath)
+	if err != nil {
+		log.Errorf("[localStruct-3] Stat: %v", err)
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Path:        path,
+		Size:        fileInfo.Size(),
+		ContentType: contentType,
+		UpdatedAt:   fileInfo.ModTime(),
+	}, nil
+}
+
+func (l *localStruct) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	fullPath, err := l.resolve(path)
+	if err != nil {
+		log.Errorf("[localStruct-1] Download: %v", err)
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Errorf("[localStruct-2] Download: %v", err)
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Prefix implements Server.
+func (l *localStruct) Prefix() string {
+	return l.prefix
+}
+
+// ServeHTTP implements Server. Files outside public/ need a URL from
+// SignedURL, and every upload needs one from SignedUpload.
+func (l *localStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := cleanPath(strings.TrimPrefix(r.URL.Path, l.prefix))
+
+	if r.Method == http.MethodPut {
+		l.serveUpload(w, r, path)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	if !strings.HasPrefix(path, localPublicPrefix) && !l.verify(query, http.MethodGet, path, query.Get("expires")) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	info, err := l.Stat(r.Context(), path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	fullPath, _ := l.resolve(path)
+	file, err := os.Open(fullPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	http.ServeContent(w, r, "", info.UpdatedAt, file)
+}
+
+func (l *localStruct) serveUpload(w http.ResponseWriter, r *http.Request, path string) {
+	query := r.URL.Query()
+	contentType, size := query.Get("content_type"), query.Get("max_size")
+
+	if !l.verify(query, http.MethodPut, path, query.Get("expires"), contentType, size) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != contentType {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	maxSize, _ := strconv.ParseInt(size, 10, 64)
+	if r.ContentLength > maxSize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if _, err := l.UploadFile(r.Context(), path, http.MaxBytesReader(w, r.Body, maxSize), contentType); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// resolve maps path to a file under dir.
+func (l *localStruct) resolve(path string) (string, error) {
+	cleaned := cleanPath(path)
+	if cleaned == "" {
+		return "", errors.New("empty storage path")
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(cleaned)), nil
+}
+
+// cleanPath cleans path as if it were absolute, so ".." can never climb
+// above the storage root, and drops the leading slash.
+func cleanPath(path string) string {
+	return strings.TrimPrefix(pathpkg.Clean("/"+path), "/")
+}
+
+// sign covers the method as well, so a download URL can never be used to
+// upload.
+func (l *localStruct) sign(parts ...string) string {
+	mac := hmac.New(sha256.New, l.signingKey)
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature in query against parts, which have to be
+// given in the order they were signed in.
+func (l *localStruct) verify(query url.Values, parts ...string) bool {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	expected := l.sign(parts...)
+	return hmac.Equal([]byte(expected), []byte(query.Get("signature")))
+}
+
+func detectContentType(fullPath string) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(fullPath)); contentType != "" {
+		return contentType, nil
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return http.DetectContentType(head[:n]), nil
+}
@@ -0,0 +1,67 @@
+package storage_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"user-service/config"
+	"user-service/internal/adapter/storage"
+	"user-service/internal/adapter/storage/conformance"
+)
+
+func TestLocalConformance(t *testing.T) {
+	runConformance(t, config.Storage{
+		Driver: "local",
+		Local: config.LocalStorage{
+			Dir:        t.TempDir(),
+			PublicURL:  "http://localhost:8080/files",
+			SigningKey: "test-signing-key",
+		},
+	})
+}
+
+func TestLocalRequiresURLPath(t *testing.T) {
+	for _, publicURL := range []string{"http://localhost:8080", "http://localhost:8080/"} {
+		_, err := storage.New(&config.Config{Storage: config.Storage{
+			Driver: "local",
+			Local: config.LocalStorage{
+				Dir:        t.TempDir(),
+				PublicURL:  publicURL,
+				SigningKey: "test-signing-key",
+			},
+		}})
+		if err == nil {
+			t.Errorf("storage.New accepted LOCAL_STORAGE_URL %s without a path", publicURL)
+		}
+	}
+}
+
+// runConformance runs the conformance checks against the backend cfg
+// selects, the same way the storage-check command does.
+func runConformance(t *testing.T, cfg config.Storage) {
+	t.Helper()
+
+	store, err := storage.New(&config.Config{Storage: cfg})
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if server, ok := store.(storage.Server); ok {
+		client.Transport = conformance.HandlerTransport(server)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Checks rely on the ones before them, so stop at the first failure.
+	for _, result := range conformance.Run(ctx, store, client, "conformance/"+uuid.New().String()) {
+		if result.Err != nil {
+			t.Fatalf("%s: %v", result.Name, result.Err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"user-service/config"
+
+	"github.com/labstack/gommon/log"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize bounds the memory used for uploads of unknown length.
+const s3PartSize = 5 * 1024 * 1024
+
+// s3Struct works with AWS S3 and compatible stores such as MinIO.
+type s3Struct struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func newS3(cfg config.S3Storage) (StorageInterface, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publicURL := strings.TrimRight(cfg.PublicURL, "/")
+	if publicURL == "" {
+		publicURL = client.EndpointURL().String() + "/" + cfg.Bucket
+	}
+
+	return &s3Struct{
+		client:    client,
+		bucket:    cfg.Bucket,
+		publicURL: publicURL,
+	}, nil
+}
+
+func (s *s3Struct) UploadFile(ctx context.Context, path string, file io.Reader, contentType string) (string, error) {
+	size := int64(-1)
+	if sized, ok := file.(interface{ Len() int }); ok {
+		size = int64(sized.Len())
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucket, path, file, size, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    s3PartSize,
+	})
+	if err != nil {
+		log.Errorf("[s3Struct-1] UploadFile: %v", err)
+		return "", err
+	}
+
+	return s.publicURL + "/" + escapePath(path), nil
+}
+
+func (s *s3Struct) DeleteFiles(ctx context.Context, paths ...string) error {
+	for _, path := range paths {
+		if err := s.client.RemoveObject(ctx, s.bucket, path, minio.RemoveObjectOptions{}); err != nil {
+			log.Errorf("[s3Struct-1] DeleteFiles: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *s3Struct) SignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	signed, err := s.client.PresignedGetObject(ctx, s.bucket, path, expiry, nil)
+	if err != nil {
+		log.Errorf("[s3Struct-1] SignedURL: %v", err)
+		return "", err
+	}
+
+	return signed.String(), nil
+}
+
+func (s *s3Struct) Stat(ctx context.Context, path string) (*ObjectInfo, error) {
+	object, err := s.client.StatObject(ctx, s.bucket, path, minio.StatObjectOptions{})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, ErrNotFound
+		}
+
+		log.Errorf("[s3Struct-1] Stat: %v", err)
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Path:        path,
+		Size:        object.Size,
+		ContentType: object.ContentType,
+		UpdatedAt:   object.LastModified,
+	}, nil
+}
+
+func (s *s3Struct) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, path, minio.GetObjectOptions{})
+	if err != nil {
+		log.Errorf("[s3Struct-1] Download: %v", err)
+		return nil, err
+	}
+
+	// GetObject is lazy, Stat makes the request so a missing object shows up
+	// here rather than on the first Read.
+	if _, err = object.Stat(); err != nil {
+		object.Close()
+		if isS3NotFound(err) {
+			return nil, ErrNotFound
+		}
+
+		log.Errorf("[s3Struct-2] Download: %v", err)
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// SignedUpload returns a browser POST policy, which S3 checks against both
+// the content type and the size.
+func (s *s3Struct) SignedUpload(ctx context.Context, path, contentType string, maxSize int64, expiry time.Duration) (*UploadTarget, error) {
+	expiresAt := time.Now().Add(expiry)
+
+	policy := minio.NewPostPolicy()
+	for _, err := range []error{
+		policy.SetBucket(s.bucket),
+		policy.SetKey(path),
+		policy.SetExpires(expiresAt),
+		policy.SetContentType(contentType),
+		policy.SetContentLengthRange(1, maxSize),
+	} {
+		if err != nil {
+			log.Errorf("[s3Struct-1] SignedUpload: %v", err)
+			return nil, err
+		}
+	}
+
+	target, fields, err := s.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		log.Errorf("[s3Struct-2] SignedUpload: %v", err)
+		return nil, err
+	}
+
+	return &UploadTarget{
+		Method:    http.MethodPost,
+		URL:       target.String(),
+		Fields:    fields,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func isS3NotFound(err error) bool {
+	errResp := minio.ToErrorResponse(err)
+	return errResp.StatusCode == http.StatusNotFound || errResp.Code == "NoSuchKey"
+}
@@ -0,0 +1,30 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+
+	"user-service/config"
+)
+
+// TestS3Conformance runs against a real bucket, so it only runs when
+// TEST_S3_ENDPOINT is set, e.g. to a local MinIO.
+func TestS3Conformance(t *testing.T) {
+	endpoint := os.Getenv("TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_S3_ENDPOINT not set")
+	}
+
+	runConformance(t, config.Storage{
+		Driver: "s3",
+		S3: config.S3Storage{
+			Endpoint:  endpoint,
+			Region:    os.Getenv("TEST_S3_REGION"),
+			AccessKey: os.Getenv("TEST_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("TEST_S3_SECRET_KEY"),
+			Bucket:    os.Getenv("TEST_S3_BUCKET"),
+			UseSSL:    os.Getenv("TEST_S3_USE_SSL") == "true",
+			PublicURL: os.Getenv("TEST_S3_PUBLIC_URL"),
+		},
+	})
+}
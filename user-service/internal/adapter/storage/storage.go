@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"user-service/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// StorageInterface stores files under slash separated paths such as
+// public/uploads/users/1/photo.jpg. Uploading to an existing path replaces
+// the object and deleting a missing one is not an error.
+type StorageInterface interface {
+	// UploadFile returns the public URL of the object. Whether it can be
+	// fetched without signing depends on the bucket.
+	UploadFile(ctx context.Context, path string, file io.Reader, contentType string) (string, error)
+	DeleteFiles(ctx context.Context, paths ...string) error
+	SignedURL(ctx context.Context, path string, expiry time.Duration) (string, error)
+	// Stat returns ErrNotFound when there is no object at path.
+	Stat(ctx context.Context, path string) (*ObjectInfo, error)
+	// Download returns ErrNotFound when there is no object at path.
+	Download(ctx context.Context, path string) (io.ReadCloser, error)
+	// SignedUpload lets a client upload one object to path without going
+	// through this service. Backends enforce contentType and maxSize where
+	// they can, so callers still have to check the object afterwards.
+	SignedUpload(ctx context.Context, path, contentType string, maxSize int64, expiry time.Duration) (*UploadTarget, error)
+}
+
+type ObjectInfo struct {
+	Path        string
+	Size        int64
+	ContentType string
+	UpdatedAt   time.Time
+}
+
+// UploadTarget tells a client how to upload straight to the backend. For
+// POST the Fields come first in a multipart form and the file goes last in
+// a part named "file". For PUT the file is the raw body, sent with Headers.
+type UploadTarget struct {
+	Method    string
+	URL       string
+	Fields    map[string]string
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// Server is implemented by backends that serve their own files over HTTP.
+// The handler expects to be mounted at Prefix.
+type Server interface {
+	http.Handler
+	Prefix() string
+}
+
+func New(cfg *config.Config) (StorageInterface, error) {
+	switch cfg.Storage.Driver {
+	case "", "supabase":
+		return newSupabase(cfg.Storage.Supabase), nil
+	case "s3":
+		return newS3(cfg.Storage.S3)
+	case "local":
+		return newLocal(cfg.Storage.Local)
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+}
+
+// escapePath escapes each segment of path for use in a URL.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
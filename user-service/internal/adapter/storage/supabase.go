@@ -1,39 +1,225 @@
 package storage
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
+	"strings"
+	"time"
 	"user-service/config"
 
 	"github.com/labstack/gommon/log"
-	storage_go "github.com/supabase-community/storage-go"
 )
 
-type SupabaseI interface {
-	UploadFile(path string, file io.Reader) (string, error)
-}
-
+// supabaseStruct talks to the Supabase Storage REST API with one shared
+// client, so no per-request state is kept between calls.
 type supabaseStruct struct {
-	cfg *config.Config
+	url    string
+	key    string
+	bucket string
+	client *http.Client
 }
 
-func New(cfg *config.Config) SupabaseI {
+func newSupabase(cfg config.Supabase) StorageInterface {
 	return &supabaseStruct{
-		cfg: cfg,
+		url:    strings.TrimRight(cfg.URL, "/"),
+		key:    cfg.Key,
+		bucket: cfg.Bucket,
+		client: &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
-func (s *supabaseStruct) UploadFile(path string, file io.Reader) (string, error) {
-	client := storage_go.NewClient(s.cfg.Storage.URL, s.cfg.Storage.Key, map[string]string{
-		"Content-Type": "image/png",
-	})
-
-	_, err := client.UploadFile(s.cfg.Storage.Bucket, path, file)
+func (s *supabaseStruct) UploadFile(ctx context.Context, path string, file io.Reader, contentType string) (string, error) {
+	req, err := s.newRequest(ctx, http.MethodPost, "/object/"+s.bucket+"/"+escapePath(path), file)
 	if err != nil {
 		log.Errorf("[supabaseStruct-1] UploadFile: %v", err)
 		return "", err
 	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true")
+
+	if err = s.do(req, nil); err != nil {
+		log.Errorf("[supabaseStruct-2] UploadFile: %v", err)
+		return "", err
+	}
+
+	return s.url + "/object/public/" + s.bucket + "/" + escapePath(path), nil
+}
+
+func (s *supabaseStruct) DeleteFiles(ctx context.Context, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string][]string{"prefixes": paths})
+	if err != nil {
+		log.Errorf("[supabaseStruct-1] DeleteFiles: %v", err)
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, "/object/"+s.bucket, bytes.NewReader(body))
+	if err != nil {
+		log.Errorf("[supabaseStruct-2] DeleteFiles: %v", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err = s.do(req, nil); err != nil {
+		log.Errorf("[supabaseStruct-3] DeleteFiles: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *supabaseStruct) SignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	body, err := json.Marshal(map[string]int64{"expiresIn": int64(expiry.Seconds())})
+	if err != nil {
+		log.Errorf("[supabaseStruct-1] SignedURL: %v", err)
+		return "", err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPost, "/object/sign/"+s.bucket+"/"+escapePath(path), bytes.NewReader(body))
+	if err != nil {
+		log.Errorf("[supabaseStruct-2] SignedURL: %v", err)
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	result := struct {
+		SignedURL string `json:"signedURL"`
+	}{}
+	if err = s.do(req, &result); err != nil {
+		log.Errorf("[supabaseStruct-3] SignedURL: %v", err)
+		return "", err
+	}
+
+	return s.url + result.SignedURL, nil
+}
+
+func (s *supabaseStruct) Stat(ctx context.Context, path string) (*ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, "/object/authenticated/"+s.bucket+"/"+escapePath(path), nil)
+	if err != nil {
+		log.Errorf("[supabaseStruct-1] Stat: %v", err)
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Errorf("[supabaseStruct-2] Stat: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-	result := client.GetPublicUrl(s.cfg.Storage.Bucket, path)
+	// Older Storage API versions answer 400 for missing objects.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode >= 300 {
+		err = fmt.Errorf("supabase storage: %s", resp.Status)
+		log.Errorf("[supabaseStruct-3] Stat: %v", err)
+		return nil, err
+	}
+
+	info := &ObjectInfo{
+		Path:        path,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if updatedAt, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.UpdatedAt = updatedAt
+	}
+
+	return info, nil
+}
+
+func (s *supabaseStruct) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, "/object/authenticated/"+s.bucket+"/"+escapePath(path), nil)
+	if err != nil {
+		log.Errorf("[supabaseStruct-1] Download: %v", err)
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Errorf("[supabaseStruct-2] Download: %v", err)
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		err = fmt.Errorf("supabase storage: %s", resp.Status)
+		log.Errorf("[supabaseStruct-3] Download: %v", err)
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// SignedUpload returns a signed PUT URL. Supabase fixes how long the token
+// lives and only enforces the bucket wide size and type limits, so the
+// caller has to check the object once it is uploaded.
+func (s *supabaseStruct) SignedUpload(ctx context.Context, path, contentType string, maxSize int64, expiry time.Duration) (*UploadTarget, error) {
+	req, err := s.newRequest(ctx, http.MethodPost, "/object/upload/sign/"+s.bucket+"/"+escapePath(path), nil)
+	if err != nil {
+		log.Errorf("[supabaseStruct-1] SignedUpload: %v", err)
+		return nil, err
+	}
+
+	result := struct {
+		URL string `json:"url"`
+	}{}
+	if err = s.do(req, &result); err != nil {
+		log.Errorf("[supabaseStruct-2] SignedUpload: %v", err)
+		return nil, err
+	}
+
+	return &UploadTarget{
+		Method:    http.MethodPut,
+		URL:       s.url + result.URL,
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: time.Now().Add(expiry),
+	}, nil
+}
+
+func (s *supabaseStruct) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.url+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.key)
+	return req, nil
+}
+
+// do sends req and decodes a JSON response into out when it is not nil.
+func (s *supabaseStruct) do(req *http.Request, out interface{}) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("supabase storage: %s %s", resp.Status, apiErr.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
 
-	return result.SignedURL, nil
+	return json.NewDecoder(resp.Body).Decode(out)
 }
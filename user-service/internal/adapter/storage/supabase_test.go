@@ -0,0 +1,26 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+
+	"user-service/config"
+)
+
+// TestSupabaseConformance runs against a real project, so it only runs
+// when TEST_SUPABASE_STORAGE_URL is set.
+func TestSupabaseConformance(t *testing.T) {
+	url := os.Getenv("TEST_SUPABASE_STORAGE_URL")
+	if url == "" {
+		t.Skip("TEST_SUPABASE_STORAGE_URL not set")
+	}
+
+	runConformance(t, config.Storage{
+		Driver: "supabase",
+		Supabase: config.Supabase{
+			URL:    url,
+			Key:    os.Getenv("TEST_SUPABASE_STORAGE_KEY"),
+			Bucket: os.Getenv("TEST_SUPABASE_STORAGE_BUCKET"),
+		},
+	})
+}
@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,7 +15,9 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 
 	"user-service/config"
+	"user-service/internal/adapter"
 	"user-service/internal/adapter/handler"
+	"user-service/internal/adapter/message"
 	"user-service/internal/adapter/repository"
 	"user-service/internal/adapter/storage"
 	"user-service/internal/core/service"
@@ -28,19 +32,55 @@ func RunServer() {
 		log.Fatalf("[RunServer-1] %v", err)
 	}
 
-	storageHandler := storage.New(cfg)
+	storageHandler, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("[RunServer-4] %v", err)
+	}
 
 	userRepo := repository.NewUserRepository(db.DB)
 	tokenRepo := repository.NewVerificationTokenRepository(db.DB)
-
+	roleRepo := repository.NewRoleRepository(db.DB)
+	twoFactorRepo := repository.NewTwoFactorRepository(db.DB)
+	passwordHistoryRepo := repository.NewPasswordHistoryRepository(db.DB)
+	identityRepo := repository.NewUserIdentityRepository(db.DB)
+	accountRepo := repository.NewAccountRepository(db.DB)
+	auditLogRepo := repository.NewAuditLogRepository(db.DB)
+	addressRepo := repository.NewAddressRepository(db.DB)
+	outboxRepo := repository.NewOutboxRepository(db.DB)
+
+	auditService := service.NewAuditService(auditLogRepo)
 	jwtService := service.NewJwtService(cfg)
-	userService := service.NewUserService(userRepo, cfg, jwtService, tokenRepo)
+	sessionService := service.NewSessionService(cfg, jwtService, auditService)
+	loginGuard := service.NewLoginGuardService(cfg)
+	twoFactorService := service.NewTwoFactorService(cfg, twoFactorRepo, userRepo, sessionService)
+	passwordHasher := service.NewPasswordHasher(cfg)
+	passwordPolicy := service.NewPasswordPolicy(cfg, passwordHasher)
+	passwordPolicyService := service.NewPasswordPolicyService(cfg, passwordPolicy, passwordHistoryRepo)
+	userService := service.NewUserService(userRepo, cfg, sessionService, tokenRepo, outboxRepo, loginGuard, twoFactorService, passwordPolicyService, passwordHasher, auditService)
+	roleService := service.NewRoleService(roleRepo, auditService)
+	oauthService := service.NewOAuthService(cfg, identityRepo, userRepo, sessionService, twoFactorService, passwordHasher)
+	passwordlessService := service.NewPasswordlessService(cfg, userRepo, tokenRepo, sessionService, twoFactorService)
+	phoneVerificationService := service.NewPhoneVerificationService(cfg, userRepo, tokenRepo)
+	profilePhotoService := service.NewProfilePhotoService(cfg, userRepo, storageHandler, auditService)
+	accountService := service.NewAccountService(cfg, accountRepo, userRepo, sessionService, profilePhotoService, loginGuard, passwordHasher, auditService)
+	addressService := service.NewAddressService(addressRepo)
+	publisher := message.NewPublisher(cfg)
+	defer publisher.Close()
+	outboxRelayService := service.NewOutboxRelayService(cfg, outboxRepo, publisher)
 
 	e := echo.New()
+	e.IPExtractor, err = ipExtractor(cfg.App.TrustedProxies)
+	if err != nil {
+		log.Fatalf("[RunServer-5] %v", err)
+	}
 	e.Use(middleware.CORS())
+	e.Use(adapter.NewMiddlewareAdapter(cfg, jwtService).AuditContext())
 
 	customValidator := validator.NewValidator()
 	en.RegisterDefaultTranslations(customValidator.Validator, customValidator.Translator)
+	if err = customValidator.RegisterPasswordPolicy(passwordPolicy); err != nil {
+		log.Fatalf("[RunServer-3] %v", err)
+	}
 	e.Validator = customValidator
 
 	e.GET("api/check", func(c echo.Context) error {
@@ -48,7 +88,21 @@ func RunServer() {
 	})
 
 	handler.NewUserHandler(e, userService, cfg, jwtService)
-	handler.NewUploadImage(e, cfg, storageHandler, jwtService)
+	handler.NewUploadImage(e, cfg, profilePhotoService, jwtService)
+	handler.NewRoleHandler(e, roleService, cfg, jwtService)
+	handler.NewSessionHandler(e, sessionService, cfg, jwtService)
+	handler.NewTwoFactorHandler(e, twoFactorService, cfg, jwtService)
+	handler.NewOAuthHandler(e, oauthService, cfg, jwtService)
+	handler.NewPasswordlessHandler(e, passwordlessService, cfg, jwtService)
+	handler.NewPhoneHandler(e, phoneVerificationService, cfg, jwtService)
+	handler.NewAccountHandler(e, accountService, cfg, jwtService)
+	handler.NewAuditHandler(e, auditService, cfg, jwtService)
+	handler.NewAddressHandler(e, addressService, cfg, jwtService)
+	handler.NewJwksHandler(e, jwtService)
+
+	if server, ok := storageHandler.(storage.Server); ok {
+		e.Any(server.Prefix()+"/*", echo.WrapHandler(server))
+	}
 
 	go func() {
 		if cfg.App.AppPort == "" {
@@ -62,6 +116,14 @@ func RunServer() {
 
 	}()
 
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go purgeDeletedAccounts(purgeCtx, accountService, cfg.Account.PurgeInterval)
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	go relayOutbox(relayCtx, outboxRelayService, cfg.Outbox.PollInterval)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	signal.Notify(quit, syscall.SIGTERM)
@@ -74,3 +136,91 @@ func RunServer() {
 	e.Shutdown(ctx)
 	log.Println("Server gracefully stopped")
 }
+
+// ipExtractor decides where c.RealIP comes from, which the login guard and
+// rate limits key on. X-Forwarded-For is only believed when the request
+// comes from one of trustedProxies, otherwise any client could pick its
+// own IP.
+func ipExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: %w", cidr, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// purgeDeletedAccounts anonymizes accounts past their deletion grace period
+// every interval until ctx is done.
+func purgeDeletedAccounts(ctx context.Context, accountService service.AccountServiceInterface, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := accountService.PurgeDeletedAccounts(ctx)
+			if err != nil {
+				log.Printf("[PurgeDeletedAccounts-1] %v", err)
+			}
+			if purged > 0 {
+				log.Printf("[PurgeDeletedAccounts] anonymized %d accounts", purged)
+			}
+		}
+	}
+}
+
+// outboxCleanupInterval is how often published outbox events past their
+// retention are deleted.
+const outboxCleanupInterval = time.Hour
+
+// relayOutbox publishes pending outbox events every interval, and cleans up
+// published ones every outboxCleanupInterval, until ctx is done.
+func relayOutbox(ctx context.Context, relayService service.OutboxRelayServiceInterface, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	cleanup := time.NewTicker(outboxCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := relayService.Relay(ctx); err != nil {
+				log.Printf("[RelayOutbox-1] %v", err)
+			}
+		case <-cleanup.C:
+			deleted, err := relayService.PurgePublished(ctx)
+			if err != nil {
+				log.Printf("[RelayOutbox-2] %v", err)
+			}
+			if deleted > 0 {
+				log.Printf("[RelayOutbox] deleted %d published events", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// AccountExportEntity is everything the user service holds about a user,
+// returned by the data export. Token values are never included.
+type AccountExportEntity struct {
+	Profile    UserEntity
+	Identities []AccountIdentityEntity
+	Tokens     []VerificationTokenEntity
+	Addresses  []AddressEntity
+	Sessions   []UserSessionEntity
+	ExportedAt time.Time
+}
+
+type AccountIdentityEntity struct {
+	Provider  string
+	Email     string
+	CreatedAt time.Time
+}
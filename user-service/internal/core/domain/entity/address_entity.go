@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+type AddressEntity struct {
+	ID            int64
+	UserID        int64
+	Label         string
+	RecipientName string
+	Phone         string
+	Street        string
+	Province      string
+	City          string
+	District      string
+	PostalCode    string
+	Notes         string
+	// Latitude and Longitude are nil when the address was not pinned on a map.
+	Latitude  *float64
+	Longitude *float64
+	IsDefault bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// DeletedAt is only set in the data export, which includes removed
+	// addresses.
+	DeletedAt *time.Time
+}
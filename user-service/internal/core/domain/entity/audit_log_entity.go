@@ -0,0 +1,68 @@
+package entity
+
+import "time"
+
+const (
+	AuditActionSignIn          = "user.sign_in"
+	AuditActionSignInFailed    = "user.sign_in_failed"
+	AuditActionPasswordChanged = "user.password_changed"
+	AuditActionPasswordReset   = "user.password_reset"
+	AuditActionProfileUpdated  = "user.profile_updated"
+	AuditActionEmailChanged    = "user.email_changed"
+	AuditActionAccountDeleted  = "user.account_deleted"
+
+	AuditActionUserSuspended       = "admin.user_suspended"
+	AuditActionUserUnsuspended     = "admin.user_unsuspended"
+	AuditActionUserDeleted         = "admin.user_deleted"
+	AuditActionUserUnlocked        = "admin.user_unlocked"
+	AuditActionPasswordResetForced = "admin.password_reset_forced"
+
+	AuditActionRoleCreated          = "role.created"
+	AuditActionRoleUpdated          = "role.updated"
+	AuditActionRoleDeleted          = "role.deleted"
+	AuditActionRoleAssigned         = "role.assigned"
+	AuditActionRoleUnassigned       = "role.unassigned"
+	AuditActionRoleTwoFactorUpdated = "role.two_factor_updated"
+
+	AuditTargetUser = "user"
+	AuditTargetRole = "role"
+)
+
+// AuditLogEntity is one recorded event. ActorID is 0 when nobody was signed
+// in, e.g. for a failed sign in.
+type AuditLogEntity struct {
+	ID         int64
+	Action     string
+	ActorID    int64
+	TargetType string
+	TargetID   int64
+	IPAddress  string
+	UserAgent  string
+	Changes    map[string]AuditChangeEntity
+	Metadata   map[string]interface{}
+	CreatedAt  time.Time
+}
+
+type AuditChangeEntity struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditClientEntity is who made the current request, carried in the request
+// context so services can record it without extra parameters.
+type AuditClientEntity struct {
+	ActorID   int64
+	IPAddress string
+	UserAgent string
+}
+
+type QueryAuditLogEntity struct {
+	Action     string
+	ActorID    int64
+	TargetType string
+	TargetID   int64
+	From       *time.Time
+	To         *time.Time
+	Page       int64
+	Limit      int64
+}
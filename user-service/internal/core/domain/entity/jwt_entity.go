@@ -1,10 +1,27 @@
 package entity
 
 type JwtUserData struct {
-	CreatedAt string `json:"created_at"`
-	Email     string `json:"email"`
-	LoggedIn  bool   `json:"logged_in"`
-	Name      string `json:"name"`
-	Token     string `json:"token"`
-	UserID    int64  `json:"user_id"`
+	CreatedAt string   `json:"created_at"`
+	Email     string   `json:"email"`
+	LoggedIn  bool     `json:"logged_in"`
+	Name      string   `json:"name"`
+	Roles     []string `json:"roles"`
+	Token     string   `json:"token"`
+	UserID    int64    `json:"user_id"`
+	FamilyID  string   `json:"family_id"`
+}
+
+type JWKEntity struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSEntity struct {
+	Keys []JWKEntity `json:"keys"`
 }
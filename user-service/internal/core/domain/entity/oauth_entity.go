@@ -0,0 +1,18 @@
+package entity
+
+// OAuthIdentityEntity is the verified identity returned by an OIDC provider.
+type OAuthIdentityEntity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OAuthStateEntity is kept in Redis between the redirect to the provider
+// and its callback.
+type OAuthStateEntity struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
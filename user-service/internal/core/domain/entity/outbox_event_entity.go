@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+const OutboxAggregateUser = "user"
+
+// OutboxEventEntity is a RabbitMQ message stored in the same transaction as
+// the change it announces, and published later by the outbox relay. Events
+// of one aggregate are published in the order they were stored.
+type OutboxEventEntity struct {
+	ID            int64
+	AggregateType string
+	AggregateID   int64
+	Exchange      string
+	RoutingKey    string
+	Payload       []byte
+	Attempts      int
+	CreatedAt     time.Time
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// PhotoUploadEntity tells the client where to upload a profile photo
+// directly to storage. See storage.UploadTarget for how Fields and Headers
+// are used.
+type PhotoUploadEntity struct {
+	ID        string
+	Method    string
+	URL       string
+	Fields    map[string]string
+	Headers   map[string]string
+	MaxSize   int64
+	ExpiresAt time.Time
+}
+
+// PhotoUploadStateEntity is kept in Redis between handing out an upload URL
+// and the client completing the upload.
+type PhotoUploadStateEntity struct {
+	UserID      int64  `json:"user_id"`
+	Path        string `json:"path"`
+	ContentType string `json:"content_type"`
+	MaxSize     int64  `json:"max_size"`
+}
@@ -1,6 +1,7 @@
 package entity
 
 type RoleEntity struct {
-	ID   int64
-	Name string
+	ID               int64
+	Name             string
+	RequireTwoFactor bool
 }
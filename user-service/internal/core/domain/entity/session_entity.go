@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// SessionEntity is returned by sign-in flows. When the user still has to
+// pass two-factor authentication only TwoFactorChallenge is set, and
+// TwoFactorSetup tells whether the user must enroll first.
+type SessionEntity struct {
+	AccessToken        string
+	RefreshToken       string
+	FamilyID           string
+	ExpiresIn          int64
+	TwoFactorChallenge string
+	TwoFactorSetup     bool
+}
+
+type SessionClientEntity struct {
+	UserAgent string
+	IPAddress string
+}
+
+type RefreshTokenEntity struct {
+	UserID    int64     `json:"user_id"`
+	FamilyID  string    `json:"family_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RefreshFamilyEntity struct {
+	UserID      int64     `json:"user_id"`
+	AccessToken string    `json:"access_token"`
+	Revoked     bool      `json:"revoked"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+type UserSessionEntity struct {
+	ID         string
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	Current    bool
+}
@@ -0,0 +1,18 @@
+package entity
+
+type TwoFactorEntity struct {
+	UserID  int64
+	Email   string
+	Secret  string
+	Enabled bool
+}
+
+type TwoFactorEnrollmentEntity struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type TwoFactorChallengeEntity struct {
+	UserID int64 `json:"user_id"`
+	Setup  bool  `json:"setup"`
+}
@@ -1,16 +1,67 @@
 package entity
 
+import "time"
+
 type UserEntity struct {
-	ID         int64
+	ID          int64
+	Name        string
+	Email       string
+	Password    string
+	RoleName    string
+	Roles       []string
+	Address     string
+	Lat         *float64
+	Lng         *float64
+	Phone       string
+	Photo       string
+	IsVerified  bool
+	IsSuspended bool
+	Token       string
+	CreatedAt   time.Time
+	// TwoFactorRequired is set when any of the user's roles requires 2FA.
+	TwoFactorEnabled  bool
+	TwoFactorRequired bool
+	// PhoneVerified is reset whenever Phone changes.
+	PhoneVerified bool
+	// PendingEmail replaces Email once the new address is confirmed.
+	PendingEmail string
+	// Photo is the full size picture. PhotoPath is its storage path, the
+	// other variants sit next to it.
+	PhotoMedium    string
+	PhotoThumbnail string
+	PhotoPath      string
+}
+
+type ProfilePhotoEntity struct {
+	URL          string
+	MediumURL    string
+	ThumbnailURL string
+	Path         string
+}
+
+type QueryUserEntity struct {
+	Search     string
 	Name       string
 	Email      string
-	Password   string
 	RoleName   string
-	Address    string
-	Lat        string
-	Lng        string
-	Phone      string
-	Photo      string
-	IsVerified bool
-	Token      string
+	IsVerified *bool
+	Page       int64
+	Limit      int64
+	OrderBy    string
+	OrderType  string
+}
+
+// QueryNearbyUserEntity finds users around a point. RadiusMeters of 0 returns
+// the nearest Limit users regardless of distance.
+type QueryNearbyUserEntity struct {
+	Lat          float64
+	Lng          float64
+	RadiusMeters float64
+	RoleName     string
+	Limit        int64
+}
+
+type NearbyUserEntity struct {
+	UserEntity
+	DistanceMeters float64
 }
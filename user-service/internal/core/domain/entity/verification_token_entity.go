@@ -8,5 +8,16 @@ type VerificationTokenEntity struct {
 	Token     string
 	TokenType string
 	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
 	User      UserEntity
 }
+
+const (
+	TokenTypeEmailVerification = "email_verification"
+	TokenTypeForgotPassword    = "forgot_password"
+	TokenTypeMagicLink         = "magic_link"
+	TokenTypeLoginOTP          = "login_otp"
+	TokenTypePhoneOTP          = "phone_verification"
+	TokenTypeEmailChange       = "email_change"
+)
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type AuditLog struct {
+	ID         int64 `gorm:"primaryKey"`
+	Action     string
+	ActorID    *int64
+	TargetType *string
+	TargetID   *int64
+	IPAddress  string
+	UserAgent  string
+	Changes    *string `gorm:"type:jsonb"`
+	Metadata   *string `gorm:"type:jsonb"`
+	CreatedAt  time.Time
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type OutboxEvent struct {
+	ID            int64 `gorm:"primaryKey"`
+	AggregateType string
+	AggregateID   int64
+	Exchange      string
+	RoutingKey    string
+	Payload       string `gorm:"type:jsonb"`
+	Attempts      int
+	LastError     *string
+	NextAttemptAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	PublishedAt   *time.Time
+	CreatedAt     time.Time
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+type PasswordHistory struct {
+	ID           int64 `gorm:"primaryKey"`
+	UserID       int64 `gorm:"index"`
+	PasswordHash string
+	CreatedAt    time.Time
+}
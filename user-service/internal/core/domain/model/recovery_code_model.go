@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type RecoveryCode struct {
+	ID        int64 `gorm:"primaryKey"`
+	UserID    int64 `gorm:"index"`
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
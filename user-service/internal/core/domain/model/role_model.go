@@ -3,10 +3,11 @@ package model
 import "time"
 
 type Role struct {
-	ID        int64 `gorm:"primaryKey"`
-	Name      string
-	Users     []User `gorm:"many2many:user_role"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt *time.Time
+	ID               int64 `gorm:"primaryKey"`
+	Name             string
+	RequireTwoFactor bool
+	Users            []User `gorm:"many2many:user_role"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        *time.Time
 }
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type UserAddress struct {
+	ID            int64 `gorm:"primaryKey"`
+	UserID        int64 `gorm:"index"`
+	Label         string
+	RecipientName string
+	Phone         string
+	Street        string
+	Province      string
+	City          string
+	District      string
+	PostalCode    string
+	Notes         string
+	Latitude      *float64
+	Longitude     *float64
+	IsDefault     bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time
+}
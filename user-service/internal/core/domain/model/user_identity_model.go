@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type UserIdentity struct {
+	ID        int64 `gorm:"primaryKey"`
+	UserID    int64 `gorm:"index"`
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
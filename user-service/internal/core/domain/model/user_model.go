@@ -3,18 +3,27 @@ package model
 import "time"
 
 type User struct {
-	ID         int64 `gorm:"primaryKey"`
-	Name       string
-	Email      string
-	Password   string
-	Address    string
-	Phone      string
-	Photo      string
-	Lat        string
-	Lng        string
-	IsVerified bool
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DeletedAt  *time.Time
-	Roles      []*Role `gorm:"many2many:user_role"`
+	ID               int64 `gorm:"primaryKey"`
+	Name             string
+	Email            string
+	PendingEmail     string
+	Password         string
+	Address          string
+	Phone            string
+	PhoneVerified    bool
+	Photo            string
+	PhotoMedium      string
+	PhotoThumbnail   string
+	PhotoPath        string
+	Lat              *float64
+	Lng              *float64
+	IsVerified       bool
+	IsSuspended      bool
+	TwoFactorSecret  string
+	TwoFactorEnabled bool
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        *time.Time
+	AnonymizedAt     *time.Time
+	Roles            []*Role `gorm:"many2many:user_role"`
 }
@@ -8,6 +8,7 @@ type VerificationToken struct {
 	Token     string
 	TokenType string
 	ExpiresAt time.Time
+	UsedAt    *time.Time
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter/message"
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+	"user-service/utils/password"
+)
+
+const purgeBatchSize = 100
+
+// AccountServiceInterface covers the user's own account lifecycle: deleting
+// it and exporting the data held about it.
+type AccountServiceInterface interface {
+	DeleteAccount(ctx context.Context, userID int64, currentPassword, currentFamilyID string) error
+	ExportData(ctx context.Context, userID int64, currentFamilyID string) (*entity.AccountExportEntity, error)
+	PurgeDeletedAccounts(ctx context.Context) (int, error)
+}
+
+type accountService struct {
+	cfg            *config.Config
+	repo           repository.AccountRepositoryInterface
+	userRepo       repository.UserRepositoryInterface
+	sessionService SessionServiceInterface
+	photoService   ProfilePhotoServiceInterface
+	loginGuard     LoginGuardServiceInterface
+	hasher         password.Hasher
+	audit          AuditServiceInterface
+}
+
+// DeleteAccount implements AccountServiceInterface.
+// The user confirms with the password or, lacking one as after an OAuth
+// sign-up, by using a session that signed in within the reauth window.
+// Wrong passwords count towards the sign-in lockout. The account is soft
+// deleted right away and anonymized by PurgeDeletedAccounts once the grace
+// period has passed.
+func (a *accountService) DeleteAccount(ctx context.Context, userID int64, currentPassword, currentFamilyID string) error {
+	user, err := a.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Errorf("[AccountService-1] DeleteAccount: %v", err)
+		return err
+	}
+
+	if currentPassword == "" {
+		if err = a.checkRecentSignIn(ctx, userID, currentFamilyID); err != nil {
+			log.Errorf("[AccountService-2] DeleteAccount: %v", err)
+			return err
+		}
+	} else if err = a.checkPassword(ctx, user, currentPassword); err != nil {
+		log.Errorf("[AccountService-2] DeleteAccount: %v", err)
+		return err
+	}
+
+	deleted, err := message.UserEvent(message.EventUserDeleted, map[string]interface{}{
+		"user_id":      userID,
+		"anonymize_at": time.Now().Add(a.cfg.Account.DeletionGracePeriod).UTC(),
+	})
+	if err != nil {
+		log.Errorf("[AccountService-3] DeleteAccount: %v", err)
+		return err
+	}
+
+	if err = a.userRepo.SoftDeleteUser(ctx, userID, deleted); err != nil {
+		log.Errorf("[AccountService-4] DeleteAccount: %v", err)
+		return err
+	}
+
+	a.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionAccountDeleted,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   userID,
+	})
+
+	if err = a.sessionService.RevokeAllUserSessions(ctx, userID, ""); err != nil {
+		log.Errorf("[AccountService-5] DeleteAccount: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// checkPassword verifies the password behind the same lockout as SignIn.
+func (a *accountService) checkPassword(ctx context.Context, user *entity.UserEntity, currentPassword string) error {
+	ip := auditClientFromContext(ctx).IPAddress
+
+	if err := a.loginGuard.Check(ctx, user.Email, ip); err != nil {
+		return err
+	}
+
+	if ok, _ := a.hasher.Verify(currentPassword, user.Password); !ok {
+		if _, err := a.loginGuard.RegisterFailure(ctx, user.Email, ip); err != nil {
+			log.Errorf("[AccountService-1] checkPassword: %v", err)
+		}
+		return errors.New("401")
+	}
+
+	return a.loginGuard.Reset(ctx, user.Email)
+}
+
+// checkRecentSignIn returns 403 unless the current session signed in
+// within cfg.Account.ReauthWindow. Refreshing a session keeps its sign-in
+// time, so only a new sign-in passes.
+func (a *accountService) checkRecentSignIn(ctx context.Context, userID int64, currentFamilyID string) error {
+	sessions, err := a.sessionService.ListUserSessions(ctx, userID, currentFamilyID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.Current && time.Since(session.CreatedAt) <= a.cfg.Account.ReauthWindow {
+			return nil
+		}
+	}
+
+	return errors.New("403")
+}
+
+// ExportData implements AccountServiceInterface.
+func (a *accountService) ExportData(ctx context.Context, userID int64, currentFamilyID string) (*entity.AccountExportEntity, error) {
+	export, err := a.repo.GetExportData(ctx, userID)
+	if err != nil {
+		log.Errorf("[AccountService-1] ExportData: %v", err)
+		return nil, err
+	}
+
+	export.Sessions, err = a.sessionService.ListUserSessions(ctx, userID, currentFamilyID)
+	if err != nil {
+		log.Errorf("[AccountService-2] ExportData: %v", err)
+		return nil, err
+	}
+
+	export.ExportedAt = time.Now()
+	return export, nil
+}
+
+// PurgeDeletedAccounts implements AccountServiceInterface.
+// It anonymizes accounts deleted longer than the grace period ago and
+// returns how many were processed.
+func (a *accountService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	deletedBefore := time.Now().Add(-a.cfg.Account.DeletionGracePeriod)
+	purged := 0
+
+	for {
+		users, err := a.repo.GetExpiredDeletedUsers(ctx, deletedBefore, purgeBatchSize)
+		if err != nil {
+			log.Errorf("[AccountService-1] PurgeDeletedAccounts: %v", err)
+			return purged, err
+		}
+
+		for _, user := range users {
+			// The photo goes first: once photo_path is cleared nothing
+			// points at the files any more.
+			if err = a.photoService.DeleteFiles(ctx, user.PhotoPath); err != nil {
+				log.Errorf("[AccountService-2] PurgeDeletedAccounts: %v", err)
+				return purged, err
+			}
+
+			if err = a.repo.AnonymizeUser(ctx, user.ID); err != nil {
+				log.Errorf("[AccountService-3] PurgeDeletedAccounts: %v", err)
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(users) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func NewAccountService(cfg *config.Config, repo repository.AccountRepositoryInterface, userRepo repository.UserRepositoryInterface, sessionService SessionServiceInterface, photoService ProfilePhotoServiceInterface, loginGuard LoginGuardServiceInterface, hasher password.Hasher, audit AuditServiceInterface) AccountServiceInterface {
+	return &accountService{
+		cfg:            cfg,
+		repo:           repo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		photoService:   photoService,
+		loginGuard:     loginGuard,
+		hasher:         hasher,
+		audit:          audit,
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+
+	"github.com/labstack/gommon/log"
+
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+)
+
+// maxAddresses caps the address book so a single account cannot grow it
+// without bound.
+const maxAddresses = 20
+
+type AddressServiceInterface interface {
+	GetAll(ctx context.Context, userID int64) ([]entity.AddressEntity, error)
+	GetByID(ctx context.Context, userID, id int64) (*entity.AddressEntity, error)
+	Create(ctx context.Context, req entity.AddressEntity) (*entity.AddressEntity, error)
+	Update(ctx context.Context, req entity.AddressEntity) error
+	Delete(ctx context.Context, userID, id int64) error
+	SetDefault(ctx context.Context, userID, id int64) error
+}
+
+type addressService struct {
+	repo repository.AddressRepositoryInterface
+}
+
+// GetAll implements AddressServiceInterface.
+func (a *addressService) GetAll(ctx context.Context, userID int64) ([]entity.AddressEntity, error) {
+	return a.repo.GetAll(ctx, userID)
+}
+
+// GetByID implements AddressServiceInterface.
+func (a *addressService) GetByID(ctx context.Context, userID, id int64) (*entity.AddressEntity, error) {
+	return a.repo.GetByID(ctx, userID, id)
+}
+
+// Create implements AddressServiceInterface.
+// The first address a user adds always becomes the default.
+func (a *addressService) Create(ctx context.Context, req entity.AddressEntity) (*entity.AddressEntity, error) {
+	address, err := a.repo.Create(ctx, req, maxAddresses)
+	if err != nil {
+		log.Errorf("[AddressService-1] Create: %v", err)
+		return nil, err
+	}
+
+	return address, nil
+}
+
+// Update implements AddressServiceInterface.
+// Clearing the default flag is ignored, a user always keeps one default
+// address; another address has to be made the default instead.
+func (a *addressService) Update(ctx context.Context, req entity.AddressEntity) error {
+	address, err := a.repo.GetByID(ctx, req.UserID, req.ID)
+	if err != nil {
+		log.Errorf("[AddressService-1] Update: %v", err)
+		return err
+	}
+
+	if err = a.repo.Update(ctx, req); err != nil {
+		log.Errorf("[AddressService-2] Update: %v", err)
+		return err
+	}
+
+	if req.IsDefault && !address.IsDefault {
+		if err = a.repo.SetDefault(ctx, req.UserID, req.ID); err != nil {
+			log.Errorf("[AddressService-3] Update: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete implements AddressServiceInterface.
+func (a *addressService) Delete(ctx context.Context, userID, id int64) error {
+	return a.repo.Delete(ctx, userID, id)
+}
+
+// SetDefault implements AddressServiceInterface.
+func (a *addressService) SetDefault(ctx context.Context, userID, id int64) error {
+	return a.repo.SetDefault(ctx, userID, id)
+}
+
+func NewAddressService(repo repository.AddressRepositoryInterface) AddressServiceInterface {
+	return &addressService{
+		repo: repo,
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/labstack/gommon/log"
+
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+)
+
+type auditClientKey struct{}
+
+// WithAuditClient stores the caller's IP address and user agent in ctx.
+func WithAuditClient(ctx context.Context, ipAddress, userAgent string) context.Context {
+	client := auditClientFromContext(ctx)
+	client.IPAddress, client.UserAgent = ipAddress, userAgent
+	return context.WithValue(ctx, auditClientKey{}, client)
+}
+
+// WithAuditActor stores the signed in user making the request in ctx.
+func WithAuditActor(ctx context.Context, actorID int64) context.Context {
+	client := auditClientFromContext(ctx)
+	client.ActorID = actorID
+	return context.WithValue(ctx, auditClientKey{}, client)
+}
+
+func auditClientFromContext(ctx context.Context) entity.AuditClientEntity {
+	client, _ := ctx.Value(auditClientKey{}).(entity.AuditClientEntity)
+	return client
+}
+
+// auditChanges collects the fields that differ between two versions of a
+// record.
+type auditChanges map[string]entity.AuditChangeEntity
+
+func (a auditChanges) add(field string, oldValue, newValue interface{}) auditChanges {
+	if !reflect.DeepEqual(oldValue, newValue) {
+		a[field] = entity.AuditChangeEntity{Old: oldValue, New: newValue}
+	}
+	return a
+}
+
+// AuditServiceInterface records security relevant events and lets admins
+// search them.
+type AuditServiceInterface interface {
+	Record(ctx context.Context, entry entity.AuditLogEntity)
+	GetAll(ctx context.Context, query entity.QueryAuditLogEntity) ([]entity.AuditLogEntity, int64, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditLogRepositoryInterface
+}
+
+// Record implements AuditServiceInterface.
+// Actor, IP address and user agent are taken from ctx unless set on entry.
+// Failures are logged and never returned, so auditing cannot break the
+// action being audited.
+func (a *auditService) Record(ctx context.Context, entry entity.AuditLogEntity) {
+	client := auditClientFromContext(ctx)
+	if entry.ActorID == 0 {
+		entry.ActorID = client.ActorID
+	}
+	if entry.IPAddress == "" {
+		entry.IPAddress = client.IPAddress
+	}
+	if entry.UserAgent == "" {
+		entry.UserAgent = client.UserAgent
+	}
+
+	if err := a.repo.Create(ctx, entry); err != nil {
+		log.Errorf("[AuditService-1] Record: %s: %v", entry.Action, err)
+	}
+}
+
+// GetAll implements AuditServiceInterface.
+func (a *auditService) GetAll(ctx context.Context, query entity.QueryAuditLogEntity) ([]entity.AuditLogEntity, int64, int64, error) {
+	return a.repo.GetAll(ctx, query)
+}
+
+func NewAuditService(repo repository.AuditLogRepositoryInterface) AuditServiceInterface {
+	return &auditService{
+		repo: repo,
+	}
+}
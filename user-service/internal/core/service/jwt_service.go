@@ -1,46 +1,232 @@
 package service
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
-	"user-service/config"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/core/domain/entity"
 )
 
 type JwtServiceInterface interface {
-	GenerateToken(userID int64) (string, error)
+	GenerateToken(userID int64, roles []string) (string, error)
 	ValidateToken(token string) (*jwt.Token, error)
+	GetJWKS() entity.JWKSEntity
+}
+
+// jwtKey is one entry of the key ring. Keys loaded from a public key file
+// have no private part and are kept only to verify tokens they signed
+// before being rotated out.
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
 }
 
 type jwtService struct {
-	secretKey string
-	issuer    string
+	secretKey  string
+	issuer     string
+	ttl        time.Duration
+	keys       map[string]*jwtKey
+	signingKey *jwtKey
 }
 
-func (s *jwtService) GenerateToken(userID int64) (string, error) {
+func (s *jwtService) GenerateToken(userID int64, roles []string) (string, error) {
 	claims := jwt.MapClaims{}
 	claims["user_id"] = userID
+	claims["roles"] = roles
 	claims["iss"] = s.issuer
-	claims["exp"] = jwt.NewNumericDate(time.Now().Add(time.Hour * 24))
+	claims["exp"] = jwt.NewNumericDate(time.Now().Add(s.ttl))
+
+	if s.signingKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(s.secretKey))
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(s.signingKey.method, claims)
+	token.Header["kid"] = s.signingKey.kid
 
-	return token.SignedString([]byte(s.secretKey))
+	return token.SignedString(s.signingKey.private)
 }
 
 func (s *jwtService) ValidateToken(encodedToken string) (*jwt.Token, error) {
 	return jwt.Parse(encodedToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if s.signingKey == nil {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, jwt.ErrSignatureInvalid
+			}
+
+			return []byte(s.secretKey), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, jwt.ErrSignatureInvalid
 		}
 
-		return []byte(s.secretKey), nil
-	})
+		return key.public, nil
+	}, jwt.WithIssuer(s.issuer))
 }
 
+// GetJWKS implements JwtServiceInterface.
+// HS256 secrets are never published, so the set is empty in that mode.
+func (s *jwtService) GetJWKS() entity.JWKSEntity {
+	jwks := entity.JWKSEntity{Keys: []entity.JWKEntity{}}
+
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		key := s.keys[kid]
+		jwk := entity.JWKEntity{
+			Kid: key.kid,
+			Alg: key.method.Alg(),
+			Use: "sig",
+		}
+
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// loadJwtKeys reads every *.pem file in dir. The file name without extension
+// is used as the key ID. RSA keys sign with RS256 and Ed25519 keys with EdDSA.
+func loadJwtKeys(dir string) (map[string]*jwtKey, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*jwtKey{}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		block, _ := pem.Decode(raw)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no PEM data found", file)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		key, err := parseJwtKey(kid, block)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		keys[kid] = key
+	}
+
+	return keys, nil
+}
+
+func parseJwtKey(kid string, block *pem.Block) (*jwtKey, error) {
+	var parsed interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &jwtKey{kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("unsupported key type, use RSA or Ed25519")
+	}
+
+	return key, nil
+}
+
+// NewJwtService signs with HS256 and JWT_SECRET_KEY unless JWT_KEYS_DIR is set.
+// With a key directory, JWT_ACTIVE_KID picks the signing key (defaulting to the
+// last private key by name) and the remaining keys stay valid for verification.
 func NewJwtService(cfg *config.Config) JwtServiceInterface {
-	return &jwtService{
+	svc := &jwtService{
 		secretKey: cfg.App.JwtSecretKey,
 		issuer:    cfg.App.JwtIssuer,
+		ttl:       cfg.App.JwtAccessTokenTTL,
+		keys:      map[string]*jwtKey{},
+	}
+
+	if cfg.App.JwtKeysDir == "" {
+		return svc
+	}
+
+	keys, err := loadJwtKeys(cfg.App.JwtKeysDir)
+	if err != nil {
+		log.Fatalf("[NewJwtService-1] load keys: %v", err)
 	}
+
+	activeKID := cfg.App.JwtActiveKID
+	if activeKID == "" {
+		for kid, key := range keys {
+			if key.private != nil && kid > activeKID {
+				activeKID = kid
+			}
+		}
+	}
+
+	signingKey, ok := keys[activeKID]
+	if !ok || signingKey.private == nil {
+		log.Fatalf("[NewJwtService-2] no private signing key %q in %s", activeKID, cfg.App.JwtKeysDir)
+	}
+
+	svc.keys = keys
+	svc.signingKey = signingKey
+	return svc
 }
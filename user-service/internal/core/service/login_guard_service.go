@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+)
+
+const (
+	loginFailPrefix  = "login_fail:"
+	loginLockPrefix  = "login_lock:"
+	loginDelayPrefix = "login_delay:"
+
+	loginMaxDelay = 30 * time.Second
+)
+
+// LoginGuardServiceInterface throttles sign-in attempts per email and per IP.
+// From the second consecutive failure an email has to wait before the next
+// attempt (1s, 2s, 4s, ... capped at 30s); reaching the attempt limit locks
+// the email or IP for the lockout duration.
+type LoginGuardServiceInterface interface {
+	Check(ctx context.Context, email, ip string) error
+	RegisterFailure(ctx context.Context, email, ip string) (bool, error)
+	Reset(ctx context.Context, email string) error
+	Unlock(ctx context.Context, email string) error
+}
+
+type loginGuardService struct {
+	redis          *redis.Client
+	maxAttempts    int
+	maxAttemptsIP  int
+	attemptWindow  time.Duration
+	lockoutTimeout time.Duration
+}
+
+// Check implements LoginGuardServiceInterface.
+// It returns 423 while the email or IP is locked and 429 while the
+// progressive delay after a failure has not elapsed.
+func (l *loginGuardService) Check(ctx context.Context, email, ip string) error {
+	email = normalizeEmail(email)
+
+	locked, err := l.redis.Exists(ctx, loginLockPrefix+"email:"+email, loginLockPrefix+"ip:"+ip).Result()
+	if err != nil {
+		log.Errorf("[LoginGuardService-1] Check: %v", err)
+		return err
+	}
+
+	if locked > 0 {
+		err = errors.New("423")
+		log.Infof("[LoginGuardService-2] Check: %s locked", email)
+		return err
+	}
+
+	delayed, err := l.redis.Exists(ctx, loginDelayPrefix+"email:"+email).Result()
+	if err != nil {
+		log.Errorf("[LoginGuardService-3] Check: %v", err)
+		return err
+	}
+
+	if delayed > 0 {
+		err = errors.New("429")
+		log.Infof("[LoginGuardService-4] Check: %s delayed", email)
+		return err
+	}
+
+	return nil
+}
+
+// RegisterFailure implements LoginGuardServiceInterface.
+// The returned bool reports whether this failure locked the email.
+func (l *loginGuardService) RegisterFailure(ctx context.Context, email, ip string) (bool, error) {
+	email = normalizeEmail(email)
+
+	ipFailures, err := l.incrementFailures(ctx, "ip:"+ip)
+	if err != nil {
+		log.Errorf("[LoginGuardService-1] RegisterFailure: %v", err)
+		return false, err
+	}
+
+	if ipFailures >= int64(l.maxAttemptsIP) {
+		log.Warnf("[LoginGuardService-2] RegisterFailure: locking ip %s", ip)
+		if err = l.lock(ctx, "ip:"+ip); err != nil {
+			log.Errorf("[LoginGuardService-3] RegisterFailure: %v", err)
+			return false, err
+		}
+	}
+
+	failures, err := l.incrementFailures(ctx, "email:"+email)
+	if err != nil {
+		log.Errorf("[LoginGuardService-4] RegisterFailure: %v", err)
+		return false, err
+	}
+
+	if failures >= int64(l.maxAttempts) {
+		log.Warnf("[LoginGuardService-5] RegisterFailure: locking email %s", email)
+		if err = l.lock(ctx, "email:"+email); err != nil {
+			log.Errorf("[LoginGuardService-6] RegisterFailure: %v", err)
+			return false, err
+		}
+		return true, nil
+	}
+
+	if failures >= 2 {
+		delay := time.Second << (failures - 2)
+		if delay > loginMaxDelay {
+			delay = loginMaxDelay
+		}
+
+		if err = l.redis.Set(ctx, loginDelayPrefix+"email:"+email, failures, delay).Err(); err != nil {
+			log.Errorf("[LoginGuardService-7] RegisterFailure: %v", err)
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// Reset implements LoginGuardServiceInterface.
+func (l *loginGuardService) Reset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+
+	if err := l.redis.Del(ctx, loginFailPrefix+"email:"+email, loginDelayPrefix+"email:"+email).Err(); err != nil {
+		log.Errorf("[LoginGuardService-1] Reset: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Unlock implements LoginGuardServiceInterface.
+func (l *loginGuardService) Unlock(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+
+	if err := l.redis.Del(ctx, loginLockPrefix+"email:"+email, loginFailPrefix+"email:"+email, loginDelayPrefix+"email:"+email).Err(); err != nil {
+		log.Errorf("[LoginGuardService-1] Unlock: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (l *loginGuardService) incrementFailures(ctx context.Context, subject string) (int64, error) {
+	key := loginFailPrefix + subject
+
+	failures, err := l.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if failures == 1 {
+		if err = l.redis.Expire(ctx, key, l.attemptWindow).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return failures, nil
+}
+
+func (l *loginGuardService) lock(ctx context.Context, subject string) error {
+	pipe := l.redis.TxPipeline()
+	pipe.Set(ctx, loginLockPrefix+subject, time.Now().String(), l.lockoutTimeout)
+	pipe.Del(ctx, loginFailPrefix+subject, loginDelayPrefix+subject)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func NewLoginGuardService(cfg *config.Config) LoginGuardServiceInterface {
+	return &loginGuardService{
+		redis:          config.NewRedisClient(),
+		maxAttempts:    cfg.Security.LoginMaxAttempts,
+		maxAttemptsIP:  cfg.Security.LoginMaxAttemptsPerIP,
+		attemptWindow:  cfg.Security.LoginAttemptWindow,
+		lockoutTimeout: cfg.Security.LoginLockoutDuration,
+	}
+}
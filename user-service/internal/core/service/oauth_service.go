@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter/oidc"
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+	"user-service/utils/password"
+)
+
+const (
+	oauthStatePrefix = "oauth_state:"
+	oauthStateTTL    = 10 * time.Minute
+)
+
+// OAuthServiceInterface signs customers in through an OpenID Connect
+// provider. The resulting session is the same one SignIn issues.
+type OAuthServiceInterface interface {
+	AuthURL(ctx context.Context, provider string) (string, error)
+	Callback(ctx context.Context, provider, code, state string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error)
+}
+
+type oauthService struct {
+	providers        map[string]oidc.ProviderInterface
+	identityRepo     repository.UserIdentityRepositoryInterface
+	userRepo         repository.UserRepositoryInterface
+	sessionService   SessionServiceInterface
+	twoFactorService TwoFactorServiceInterface
+	hasher           password.Hasher
+	redis            *redis.Client
+}
+
+// AuthURL implements OAuthServiceInterface.
+// state, nonce and the PKCE verifier are kept server side; only the state
+// and the S256 challenge go through the browser.
+func (o *oauthService) AuthURL(ctx context.Context, provider string) (string, error) {
+	oidcProvider, ok := o.providers[provider]
+	if !ok {
+		err := errors.New("404")
+		log.Errorf("[OAuthService-1] AuthURL: %v", err)
+		return "", err
+	}
+
+	state, err := generateOpaqueToken()
+	if err != nil {
+		log.Errorf("[OAuthService-2] AuthURL: %v", err)
+		return "", err
+	}
+
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		log.Errorf("[OAuthService-3] AuthURL: %v", err)
+		return "", err
+	}
+
+	codeVerifier, err := generateOpaqueToken()
+	if err != nil {
+		log.Errorf("[OAuthService-4] AuthURL: %v", err)
+		return "", err
+	}
+
+	jsonState, err := json.Marshal(entity.OAuthStateEntity{
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+	})
+	if err != nil {
+		log.Errorf("[OAuthService-5] AuthURL: %v", err)
+		return "", err
+	}
+
+	if err = o.redis.Set(ctx, oauthStatePrefix+hashToken(state), jsonState, oauthStateTTL).Err(); err != nil {
+		log.Errorf("[OAuthService-6] AuthURL: %v", err)
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	authURL, err := oidcProvider.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		log.Errorf("[OAuthService-7] AuthURL: %v", err)
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// Callback implements OAuthServiceInterface.
+func (o *oauthService) Callback(ctx context.Context, provider, code, state string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	oidcProvider, ok := o.providers[provider]
+	if !ok {
+		err := errors.New("404")
+		log.Errorf("[OAuthService-1] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	// GetDel makes the state single use.
+	jsonState, err := o.redis.GetDel(ctx, oauthStatePrefix+hashToken(state)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			err = errors.New("401")
+		}
+		log.Errorf("[OAuthService-2] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	oauthState := entity.OAuthStateEntity{}
+	if err = json.Unmarshal([]byte(jsonState), &oauthState); err != nil {
+		log.Errorf("[OAuthService-3] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	if oauthState.Provider != provider {
+		err = errors.New("401")
+		log.Errorf("[OAuthService-4] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	identity, err := oidcProvider.Exchange(ctx, code, oauthState.CodeVerifier, oauthState.Nonce)
+	if err != nil {
+		log.Errorf("[OAuthService-5] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	userID, err := o.resolveUser(ctx, *identity)
+	if err != nil {
+		log.Errorf("[OAuthService-6] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	user, err := o.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		// The identity is still linked to an account that was deleted.
+		if err.Error() == "404" {
+			err = errors.New("403")
+		}
+		log.Errorf("[OAuthService-7] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	if user.IsSuspended {
+		err = errors.New("403")
+		log.Errorf("[OAuthService-8] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	if user.TwoFactorEnabled || user.TwoFactorRequired {
+		session, err := o.twoFactorService.CreateChallenge(ctx, *user)
+		if err != nil {
+			log.Errorf("[OAuthService-9] Callback: %v", err)
+			return nil, nil, err
+		}
+		return user, session, nil
+	}
+
+	session, err := o.sessionService.CreateSession(ctx, *user, "", client)
+	if err != nil {
+		log.Errorf("[OAuthService-10] Callback: %v", err)
+		return nil, nil, err
+	}
+
+	return user, session, nil
+}
+
+// resolveUser finds the user linked to the identity. Otherwise it links the
+// identity to the account with the same email, or creates a Customer.
+// Both require the provider to have verified the email.
+func (o *oauthService) resolveUser(ctx context.Context, identity entity.OAuthIdentityEntity) (int64, error) {
+	userID, err := o.identityRepo.GetUserIDByIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return userID, nil
+	}
+	if err.Error() != "404" {
+		return 0, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return 0, errors.New("422")
+	}
+	identity.Email = strings.ToLower(identity.Email)
+
+	// OAuth users sign in through the provider; the random password only
+	// fills the column until they set one with forgot-password.
+	passwordHash, err := o.hasher.Hash(uuid.New().String())
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := o.identityRepo.GetUserByEmail(ctx, identity.Email)
+	if err != nil && err.Error() != "404" {
+		return 0, err
+	}
+
+	if existing != nil {
+		// An unverified account may have been registered by someone else
+		// with this email, so its password is replaced when linking.
+		if existing.IsVerified {
+			passwordHash = ""
+		}
+
+		if err = o.identityRepo.LinkIdentity(ctx, existing.ID, identity, passwordHash); err != nil {
+			return 0, err
+		}
+		return existing.ID, nil
+	}
+
+	if identity.Name == "" {
+		identity.Name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	return o.identityRepo.CreateUserWithIdentity(ctx, identity, passwordHash)
+}
+
+func NewOAuthService(cfg *config.Config, identityRepo repository.UserIdentityRepositoryInterface, userRepo repository.UserRepositoryInterface, sessionService SessionServiceInterface, twoFactorService TwoFactorServiceInterface, hasher password.Hasher) OAuthServiceInterface {
+	return &oauthService{
+		providers:        oidc.NewProviders(cfg),
+		identityRepo:     identityRepo,
+		userRepo:         userRepo,
+		sessionService:   sessionService,
+		twoFactorService: twoFactorService,
+		hasher:           hasher,
+		redis:            config.NewRedisClient(),
+	}
+}
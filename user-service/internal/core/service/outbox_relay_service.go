@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter/message"
+	"user-service/internal/adapter/repository"
+)
+
+const (
+	// outboxClaimLease is how long a claimed event is left alone before
+	// another relay may pick it up again.
+	outboxClaimLease = time.Minute
+	// outboxRetryBase is the delay after the first failed publish, doubled
+	// for each further failure up to cfg.Outbox.MaxBackoff.
+	outboxRetryBase = 5 * time.Second
+)
+
+// OutboxRelayServiceInterface publishes the events stored in the outbox.
+// Several instances may relay at the same time.
+type OutboxRelayServiceInterface interface {
+	Relay(ctx context.Context) (int, error)
+	PurgePublished(ctx context.Context) (int64, error)
+}
+
+type outboxRelayService struct {
+	cfg       *config.Config
+	repo      repository.OutboxRepositoryInterface
+	publisher message.PublisherInterface
+}
+
+// Relay implements OutboxRelayServiceInterface.
+// It publishes batches until nothing is due and returns how many events
+// were published. A failed event is retried later with backoff, and holds
+// back the later events of its aggregate until then.
+func (o *outboxRelayService) Relay(ctx context.Context) (int, error) {
+	published := 0
+
+	for {
+		events, err := o.repo.ClaimPending(ctx, o.cfg.Outbox.BatchSize, outboxClaimLease)
+		if err != nil {
+			log.Errorf("[OutboxRelayService-1] Relay: %v", err)
+			return published, err
+		}
+
+		if len(events) == 0 {
+			return published, nil
+		}
+
+		for _, event := range events {
+			if err = o.publisher.Publish(ctx, event); err != nil {
+				retryIn := o.backoff(event.Attempts + 1)
+				log.Errorf("[OutboxRelayService-2] Relay: event %d failed, retrying in %s: %v", event.ID, retryIn, err)
+
+				// If this fails too the lease runs out and the event is
+				// retried anyway.
+				if err = o.repo.MarkFailed(ctx, event.ID, err.Error(), retryIn); err != nil {
+					log.Errorf("[OutboxRelayService-3] Relay: %v", err)
+				}
+				continue
+			}
+
+			// Left unmarked the event is published again after the lease,
+			// which consumers have to tolerate anyway.
+			if err = o.repo.MarkPublished(ctx, event.ID); err != nil {
+				log.Errorf("[OutboxRelayService-4] Relay: %v", err)
+				continue
+			}
+
+			published++
+		}
+	}
+}
+
+// PurgePublished implements OutboxRelayServiceInterface.
+// It deletes events published longer than cfg.Outbox.Retention ago.
+func (o *outboxRelayService) PurgePublished(ctx context.Context) (int64, error) {
+	deleted, err := o.repo.DeletePublished(ctx, o.cfg.Outbox.Retention)
+	if err != nil {
+		log.Errorf("[OutboxRelayService-1] PurgePublished: %v", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// backoff returns the delay before retrying an event that has failed
+// attempts times.
+func (o *outboxRelayService) backoff(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < o.cfg.Outbox.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if o.cfg.Outbox.MaxBackoff > 0 {
+		delay = min(delay, o.cfg.Outbox.MaxBackoff)
+	}
+
+	return delay
+}
+
+func NewOutboxRelayService(cfg *config.Config, repo repository.OutboxRepositoryInterface, publisher message.PublisherInterface) OutboxRelayServiceInterface {
+	return &outboxRelayService{
+		cfg:       cfg,
+		repo:      repo,
+		publisher: publisher,
+	}
+}
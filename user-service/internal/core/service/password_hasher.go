@@ -0,0 +1,23 @@
+package service
+
+import (
+	"user-service/config"
+	"user-service/utils/password"
+)
+
+// NewPasswordHasher hashes with the configured algorithm and keeps
+// verifying hashes of the other one until they are rehashed on sign in.
+func NewPasswordHasher(cfg *config.Config) password.Hasher {
+	bcryptHasher := password.NewBcryptHasher(cfg.PasswordHash.BcryptCost)
+	argon2idHasher := password.NewArgon2idHasher(password.Argon2idParams{
+		Memory:      cfg.PasswordHash.Argon2Memory,
+		Iterations:  cfg.PasswordHash.Argon2Iterations,
+		Parallelism: cfg.PasswordHash.Argon2Parallelism,
+	})
+
+	if cfg.PasswordHash.Algorithm == "argon2id" {
+		return password.NewHasher(argon2idHasher, bcryptHasher)
+	}
+
+	return password.NewHasher(bcryptHasher, argon2idHasher)
+}
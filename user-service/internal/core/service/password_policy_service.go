@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+	"user-service/utils/password"
+)
+
+// PasswordPolicyServiceInterface applies the password policy with what is
+// known about the user, including the passwords they used before.
+type PasswordPolicyServiceInterface interface {
+	Validate(ctx context.Context, pwd string, user entity.UserEntity) error
+	Remember(ctx context.Context, userID int64, previousHash string) error
+}
+
+type passwordPolicyService struct {
+	policy      *password.Policy
+	historyRepo repository.PasswordHistoryRepositoryInterface
+	history     int
+}
+
+// Validate implements PasswordPolicyServiceInterface.
+// user.Password, when set, is the current hash and counts as history.
+func (p *passwordPolicyService) Validate(ctx context.Context, pwd string, user entity.UserEntity) error {
+	subject := password.Subject{
+		Email: user.Email,
+		Name:  user.Name,
+	}
+
+	if user.Password != "" {
+		subject.History = append(subject.History, user.Password)
+	}
+
+	if user.ID != 0 && p.history > 0 {
+		hashes, err := p.historyRepo.GetRecentHashes(ctx, user.ID, p.history)
+		if err != nil {
+			log.Errorf("[PasswordPolicyService-1] Validate: %v", err)
+			return err
+		}
+		subject.History = append(subject.History, hashes...)
+	}
+
+	return p.policy.Check(ctx, pwd, subject)
+}
+
+// Remember implements PasswordPolicyServiceInterface.
+func (p *passwordPolicyService) Remember(ctx context.Context, userID int64, previousHash string) error {
+	if p.history <= 0 || previousHash == "" {
+		return nil
+	}
+
+	return p.historyRepo.Add(ctx, userID, previousHash, p.history)
+}
+
+// NewPasswordPolicy builds the policy from config. It is shared with the
+// request validator so both report the same rules.
+func NewPasswordPolicy(cfg *config.Config, hasher password.Hasher) *password.Policy {
+	rules := []password.Rule{
+		password.MinLength(cfg.PasswordPolicy.MinLength),
+		password.CharacterClasses(cfg.PasswordPolicy.MinCharClasses),
+		password.NoPersonalInfo(),
+	}
+
+	if cfg.PasswordPolicy.History > 0 {
+		rules = append(rules, password.History(cfg.PasswordPolicy.History, hasher))
+	}
+
+	if cfg.PasswordPolicy.BreachedDir != "" {
+		rules = append(rules, password.Breached(password.NewRangeFileChecker(cfg.PasswordPolicy.BreachedDir)))
+	}
+
+	return password.NewPolicy(rules...)
+}
+
+func NewPasswordPolicyService(cfg *config.Config, policy *password.Policy, historyRepo repository.PasswordHistoryRepositoryInterface) PasswordPolicyServiceInterface {
+	return &passwordPolicyService{
+		policy:      policy,
+		historyRepo: historyRepo,
+		history:     cfg.PasswordPolicy.History,
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter/message"
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+)
+
+const passwordlessAttemptsPrefix = "passwordless_attempts:"
+
+// PasswordlessServiceInterface signs users in with a one-time code or magic
+// link sent by email instead of a password.
+type PasswordlessServiceInterface interface {
+	RequestSignIn(ctx context.Context, email string) error
+	VerifyCode(ctx context.Context, email, code string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error)
+	VerifyLink(ctx context.Context, token string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error)
+}
+
+type passwordlessService struct {
+	cfg              *config.Config
+	userRepo         repository.UserRepositoryInterface
+	repoToken        repository.VerificationTokenRepositoryInterface
+	sessionService   SessionServiceInterface
+	twoFactorService TwoFactorServiceInterface
+	redis            *redis.Client
+}
+
+// RequestSignIn implements PasswordlessServiceInterface.
+// One email carries both a 6 digit code and a magic link; either signs in,
+// and using one also uses up the other.
+func (p *passwordlessService) RequestSignIn(ctx context.Context, email string) error {
+	user, err := p.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		log.Errorf("[PasswordlessService-1] RequestSignIn: %v", err)
+		return err
+	}
+
+	if user.IsSuspended {
+		err = errors.New("403")
+		log.Errorf("[PasswordlessService-2] RequestSignIn: %v", err)
+		return err
+	}
+
+	if err = checkTokenCooldown(ctx, p.repoToken, user.ID, entity.TokenTypeLoginOTP, p.cfg.Security.TokenResendCooldown); err != nil {
+		log.Errorf("[PasswordlessService-3] RequestSignIn: %v", err)
+		return err
+	}
+
+	code, err := generateNumericCode(6)
+	if err != nil {
+		log.Errorf("[PasswordlessService-4] RequestSignIn: %v", err)
+		return err
+	}
+
+	linkToken := uuid.New().String()
+	urlMagicLink := fmt.Sprintf("%s/passwordless/verify?token=%s", p.cfg.App.UrlMagicLink, linkToken)
+	messageParam := fmt.Sprintf("your sign in code is %s, or click link below to sign in: %v. Both are valid for %d minutes.",
+		code, urlMagicLink, int(p.cfg.Security.PasswordlessTTL.Minutes()))
+	signInEmail, err := message.NotificationEvent(user.Email, messageParam)
+	if err != nil {
+		log.Errorf("[PasswordlessService-5] RequestSignIn: %v", err)
+		return err
+	}
+
+	// The email carries both tokens, so they are stored with it at once.
+	expiresAt := time.Now().Add(p.cfg.Security.PasswordlessTTL)
+	err = p.repoToken.CreateVerificationTokens(ctx, []entity.VerificationTokenEntity{
+		{
+			UserID:    user.ID,
+			Token:     otpToken(user.ID, code),
+			TokenType: entity.TokenTypeLoginOTP,
+			ExpiresAt: expiresAt,
+		},
+		{
+			UserID:    user.ID,
+			Token:     linkToken,
+			TokenType: entity.TokenTypeMagicLink,
+			ExpiresAt: expiresAt,
+		},
+	}, signInEmail)
+	if err != nil {
+		log.Errorf("[PasswordlessService-6] RequestSignIn: %v", err)
+		return err
+	}
+
+	// A new code gets a fresh set of attempts.
+	if err = p.redis.Del(ctx, passwordlessAttemptsPrefix+strconv.FormatInt(user.ID, 10)).Err(); err != nil {
+		log.Errorf("[PasswordlessService-7] RequestSignIn: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// VerifyCode implements PasswordlessServiceInterface.
+// After too many wrong codes the current code stops working and a new one
+// has to be requested.
+func (p *passwordlessService) VerifyCode(ctx context.Context, email, code string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	user, err := p.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err.Error() == "404" {
+			err = errors.New("401")
+		}
+		log.Errorf("[PasswordlessService-1] VerifyCode: %v", err)
+		return nil, nil, err
+	}
+
+	// Every try is counted before the code is checked, so concurrent
+	// guesses cannot all slip in under the limit.
+	attemptsKey := passwordlessAttemptsPrefix + strconv.FormatInt(user.ID, 10)
+	pipe := p.redis.TxPipeline()
+	attempts := pipe.Incr(ctx, attemptsKey)
+	pipe.Expire(ctx, attemptsKey, p.cfg.Security.PasswordlessTTL)
+	if _, err = pipe.Exec(ctx); err != nil {
+		log.Errorf("[PasswordlessService-2] VerifyCode: %v", err)
+		return nil, nil, err
+	}
+
+	if attempts.Val() > int64(p.cfg.Security.PasswordlessAttempts) {
+		err = errors.New("429")
+		log.Errorf("[PasswordlessService-3] VerifyCode: %v", err)
+		return nil, nil, err
+	}
+
+	if _, err = p.repoToken.ConsumeToken(ctx, otpToken(user.ID, code), entity.TokenTypeLoginOTP, entity.TokenTypeMagicLink); err != nil {
+		log.Errorf("[PasswordlessService-4] VerifyCode: %v", err)
+		if err.Error() != "401" && err.Error() != "404" {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("401")
+	}
+
+	if err = p.redis.Del(ctx, attemptsKey).Err(); err != nil {
+		log.Errorf("[PasswordlessService-5] VerifyCode: %v", err)
+	}
+
+	return p.completeSignIn(ctx, user, client)
+}
+
+// VerifyLink implements PasswordlessServiceInterface.
+func (p *passwordlessService) VerifyLink(ctx context.Context, token string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	verifyToken, err := p.repoToken.ConsumeToken(ctx, token, entity.TokenTypeMagicLink, entity.TokenTypeLoginOTP)
+	if err != nil {
+		log.Errorf("[PasswordlessService-1] VerifyLink: %v", err)
+		return nil, nil, err
+	}
+
+	user, err := p.userRepo.GetUserByID(ctx, verifyToken.UserID)
+	if err != nil {
+		log.Errorf("[PasswordlessService-2] VerifyLink: %v", err)
+		return nil, nil, err
+	}
+
+	return p.completeSignIn(ctx, user, client)
+}
+
+// completeSignIn issues the same session, or 2FA challenge, as SignIn.
+func (p *passwordlessService) completeSignIn(ctx context.Context, user *entity.UserEntity, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	if user.IsSuspended {
+		err := errors.New("403")
+		log.Errorf("[PasswordlessService-1] completeSignIn: %v", err)
+		return nil, nil, err
+	}
+
+	if user.TwoFactorEnabled || user.TwoFactorRequired {
+		session, err := p.twoFactorService.CreateChallenge(ctx, *user)
+		if err != nil {
+			log.Errorf("[PasswordlessService-2] completeSignIn: %v", err)
+			return nil, nil, err
+		}
+		return user, session, nil
+	}
+
+	session, err := p.sessionService.CreateSession(ctx, *user, "", client)
+	if err != nil {
+		log.Errorf("[PasswordlessService-3] completeSignIn: %v", err)
+		return nil, nil, err
+	}
+
+	return user, session, nil
+}
+
+// otpToken is what gets stored for a code: codes are short and can repeat
+// across users, so the stored token is bound to the user and hashed.
+func otpToken(userID int64, code string) string {
+	return hashToken(fmt.Sprintf("%d:%s", userID, code))
+}
+
+func generateNumericCode(digits int) (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < digits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+func NewPasswordlessService(cfg *config.Config, userRepo repository.UserRepositoryInterface, repoToken repository.VerificationTokenRepositoryInterface, sessionService SessionServiceInterface, twoFactorService TwoFactorServiceInterface) PasswordlessServiceInterface {
+	return &passwordlessService{
+		cfg:              cfg,
+		userRepo:         userRepo,
+		repoToken:        repoToken,
+		sessionService:   sessionService,
+		twoFactorService: twoFactorService,
+		redis:            config.NewRedisClient(),
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"user-service/config"
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+)
+
+const (
+	testPhone    = "+6281234567890"
+	testNewPhone = "+6289876543210"
+)
+
+var smsCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// phoneUserRepo keeps one user in memory. SetPhoneVerified has the same
+// condition as the real query: the number must still be the user's.
+type phoneUserRepo struct {
+	repository.UserRepositoryInterface
+	user entity.UserEntity
+}
+
+func (r *phoneUserRepo) GetUserByID(ctx context.Context, userID int64) (*entity.UserEntity, error) {
+	if userID != r.user.ID {
+		return nil, errors.New("404")
+	}
+	user := r.user
+	return &user, nil
+}
+
+func (r *phoneUserRepo) SetPhoneVerified(ctx context.Context, userID int64, phone string) error {
+	if userID != r.user.ID || phone != r.user.Phone {
+		return errors.New("404")
+	}
+	r.user.PhoneVerified = true
+	return nil
+}
+
+// phoneTokenRepo keeps tokens in memory and records the outbox events
+// stored with them. onConsume runs after a token is used, to change the
+// user in between.
+type phoneTokenRepo struct {
+	repository.VerificationTokenRepositoryInterface
+	tokens    map[string]*entity.VerificationTokenEntity
+	events    []entity.OutboxEventEntity
+	onConsume func()
+}
+
+func (r *phoneTokenRepo) CreateVerificationToken(ctx context.Context, req entity.VerificationTokenEntity, events ...entity.OutboxEventEntity) error {
+	// A new token replaces the older ones of its type, like the real one.
+	for token, existing := range r.tokens {
+		if existing.UserID == req.UserID && existing.TokenType == req.TokenType {
+			delete(r.tokens, token)
+		}
+	}
+
+	req.CreatedAt = time.Now().Add(-time.Hour)
+	r.tokens[req.Token] = &req
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *phoneTokenRepo) GetLatestToken(ctx context.Context, userID int64, tokenType string) (*entity.VerificationTokenEntity, error) {
+	return nil, errors.New("404")
+}
+
+func (r *phoneTokenRepo) ConsumeToken(ctx context.Context, token, tokenType string, siblingTypes ...string) (*entity.VerificationTokenEntity, error) {
+	stored, ok := r.tokens[token]
+	if !ok || stored.TokenType != tokenType {
+		return nil, errors.New("404")
+	}
+
+	if stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, errors.New("401")
+	}
+
+	now := time.Now()
+	stored.UsedAt = &now
+
+	if r.onConsume != nil {
+		r.onConsume()
+	}
+	return stored, nil
+}
+
+type phoneVerificationFixture struct {
+	service  *phoneVerificationService
+	userRepo *phoneUserRepo
+	repo     *phoneTokenRepo
+}
+
+func newPhoneVerificationFixture(t *testing.T) *phoneVerificationFixture {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Security.PhoneOTPTTL = 10 * time.Minute
+	cfg.Security.PhoneOTPAttempts = 3
+	cfg.Security.TokenResendCooldown = time.Minute
+
+	f := &phoneVerificationFixture{
+		userRepo: &phoneUserRepo{user: entity.UserEntity{ID: 1, Phone: testPhone}},
+		repo:     &phoneTokenRepo{tokens: map[string]*entity.VerificationTokenEntity{}},
+	}
+	f.service = &phoneVerificationService{
+		cfg:       cfg,
+		userRepo:  f.userRepo,
+		repoToken: f.repo,
+		redis:     redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}),
+	}
+
+	return f
+}
+
+// receiveCode reads the code from the last SMS queued for phone, the
+// payload the notification service hands to its SMS provider.
+func (f *phoneVerificationFixture) receiveCode(t *testing.T, phone string) string {
+	t.Helper()
+
+	if len(f.repo.events) == 0 {
+		t.Fatalf("no sms queued")
+	}
+
+	sms := struct {
+		Channel string `json:"channel"`
+		Phone   string `json:"phone"`
+		Msg     string `json:"msg"`
+	}{}
+	if err := json.Unmarshal(f.repo.events[len(f.repo.events)-1].Payload, &sms); err != nil {
+		t.Fatalf("decode sms: %v", err)
+	}
+
+	if sms.Channel != "sms" || sms.Phone != phone {
+		t.Fatalf("sms sent to %s over %q, want %s over sms", sms.Phone, sms.Channel, phone)
+	}
+
+	code := smsCodePattern.FindString(sms.Msg)
+	if code == "" {
+		t.Fatalf("no code in sms %q", sms.Msg)
+	}
+	return code
+}
+
+func TestPhoneVerificationVerifiesCode(t *testing.T) {
+	f := newPhoneVerificationFixture(t)
+	ctx := context.Background()
+
+	if err := f.service.SendCode(ctx, 1); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	code := f.receiveCode(t, testPhone)
+
+	if err := f.service.VerifyCode(ctx, 1, code); err != nil {
+		t.Fatalf("VerifyCode: %v", err)
+	}
+	if !f.userRepo.user.PhoneVerified {
+		t.Fatalf("phone not verified")
+	}
+
+	if err := f.service.VerifyCode(ctx, 1, code); err == nil || err.Error() != "401" {
+		t.Fatalf("reusing the code: error = %v, want 401", err)
+	}
+}
+
+func TestPhoneVerificationRejectsCodeForOldNumber(t *testing.T) {
+	f := newPhoneVerificationFixture(t)
+	ctx := context.Background()
+
+	if err := f.service.SendCode(ctx, 1); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	code := f.receiveCode(t, testPhone)
+
+	f.userRepo.user.Phone = testNewPhone
+
+	if err := f.service.VerifyCode(ctx, 1, code); err == nil || err.Error() != "401" {
+		t.Fatalf("VerifyCode error = %v, want 401", err)
+	}
+	if f.userRepo.user.PhoneVerified {
+		t.Fatalf("new number verified with a code sent to the old one")
+	}
+}
+
+// TestPhoneVerificationSetPhoneVerifiedRejectsOldNumber changes the number
+// after the code was accepted but before it is marked verified.
+func TestPhoneVerificationSetPhoneVerifiedRejectsOldNumber(t *testing.T) {
+	f := newPhoneVerificationFixture(t)
+	ctx := context.Background()
+
+	if err := f.service.SendCode(ctx, 1); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	code := f.receiveCode(t, testPhone)
+
+	f.repo.onConsume = func() {
+		f.userRepo.user.Phone = testNewPhone
+	}
+
+	if err := f.service.VerifyCode(ctx, 1, code); err == nil || err.Error() != "404" {
+		t.Fatalf("VerifyCode error = %v, want 404", err)
+	}
+	if f.userRepo.user.PhoneVerified {
+		t.Fatalf("new number verified with a code sent to the old one")
+	}
+}
+
+func TestPhoneVerificationLimitsAttempts(t *testing.T) {
+	f := newPhoneVerificationFixture(t)
+	ctx := context.Background()
+
+	if err := f.service.SendCode(ctx, 1); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	code := f.receiveCode(t, testPhone)
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < f.service.cfg.Security.PhoneOTPAttempts; i++ {
+		if err := f.service.VerifyCode(ctx, 1, wrong); err == nil || err.Error() != "401" {
+			t.Fatalf("attempt %d: error = %v, want 401", i+1, err)
+		}
+	}
+
+	if err := f.service.VerifyCode(ctx, 1, code); err == nil || err.Error() != "429" {
+		t.Fatalf("VerifyCode after too many attempts: error = %v, want 429", err)
+	}
+
+	// A new code comes with a fresh set of attempts.
+	if err := f.service.SendCode(ctx, 1); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	if err := f.service.VerifyCode(ctx, 1, f.receiveCode(t, testPhone)); err != nil {
+		t.Fatalf("VerifyCode with a new code: %v", err)
+	}
+}
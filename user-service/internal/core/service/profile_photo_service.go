@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter/repository"
+	"user-service/internal/adapter/storage"
+	"user-service/internal/core/domain/entity"
+	"user-service/utils/imaging"
+)
+
+const (
+	photoVariantOriginal  = "original"
+	photoVariantMedium    = "medium"
+	photoVariantThumbnail = "thumbnail"
+
+	photoUploadPrefix = "photo_upload:"
+	// photoUploadGrace lets a client that finished uploading just before
+	// the URL expired still complete the upload.
+	photoUploadGrace = 5 * time.Minute
+)
+
+// photoUploadContentTypes are the types a direct upload may be signed for,
+// the same ones imaging.Process accepts.
+var photoUploadContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// ProfilePhotoServiceInterface replaces profile photos, either from bytes
+// sent to this service or from an object the client uploaded straight to
+// storage with RequestUpload and CompleteUpload.
+type ProfilePhotoServiceInterface interface {
+	Upload(ctx context.Context, userID int64, data []byte) (*entity.ProfilePhotoEntity, error)
+	RequestUpload(ctx context.Context, userID int64, contentType string) (*entity.PhotoUploadEntity, error)
+	CompleteUpload(ctx context.Context, userID int64, uploadID string) (*entity.ProfilePhotoEntity, error)
+	DeleteFiles(ctx context.Context, photoPath string) error
+}
+
+type profilePhotoService struct {
+	cfg      *config.Config
+	repo     repository.UserRepositoryInterface
+	storage  storage.StorageInterface
+	audit    AuditServiceInterface
+	redis    *redis.Client
+	variants []imaging.Variant
+}
+
+// Upload implements ProfilePhotoServiceInterface.
+func (p *profilePhotoService) Upload(ctx context.Context, userID int64, data []byte) (*entity.ProfilePhotoEntity, error) {
+	user, err := p.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Errorf("[ProfilePhotoService-1] Upload: %v", err)
+		return nil, err
+	}
+
+	return p.attach(ctx, user, data)
+}
+
+// RequestUpload implements ProfilePhotoServiceInterface.
+// The object goes to a private pending path and only becomes the profile
+// photo once CompleteUpload has checked and processed it.
+func (p *profilePhotoService) RequestUpload(ctx context.Context, userID int64, contentType string) (*entity.PhotoUploadEntity, error) {
+	if !photoUploadContentTypes[contentType] {
+		err := errors.New("415")
+		log.Errorf("[ProfilePhotoService-1] RequestUpload: %v", err)
+		return nil, err
+	}
+
+	if _, err := p.repo.GetUserByID(ctx, userID); err != nil {
+		log.Errorf("[ProfilePhotoService-2] RequestUpload: %v", err)
+		return nil, err
+	}
+
+	uploadID := uuid.New().String()
+	state := entity.PhotoUploadStateEntity{
+		UserID:      userID,
+		Path:        fmt.Sprintf("uploads/pending/users/%d/%s", userID, uploadID),
+		ContentType: contentType,
+		MaxSize:     p.cfg.Image.MaxUploadSize,
+	}
+
+	target, err := p.storage.SignedUpload(ctx, state.Path, state.ContentType, state.MaxSize, p.cfg.Image.UploadURLTTL)
+	if err != nil {
+		log.Errorf("[ProfilePhotoService-3] RequestUpload: %v", err)
+		return nil, err
+	}
+
+	jsonState, err := json.Marshal(state)
+	if err != nil {
+		log.Errorf("[ProfilePhotoService-4] RequestUpload: %v", err)
+		return nil, err
+	}
+
+	if err = p.redis.Set(ctx, photoUploadPrefix+uploadID, jsonState, p.cfg.Image.UploadURLTTL+photoUploadGrace).Err(); err != nil {
+		log.Errorf("[ProfilePhotoService-5] RequestUpload: %v", err)
+		return nil, err
+	}
+
+	return &entity.PhotoUploadEntity{
+		ID:        uploadID,
+		Method:    target.Method,
+		URL:       target.URL,
+		Fields:    target.Fields,
+		Headers:   target.Headers,
+		MaxSize:   state.MaxSize,
+		ExpiresAt: target.ExpiresAt,
+	}, nil
+}
+
+// CompleteUpload implements ProfilePhotoServiceInterface.
+// Not every backend enforces the limits of the signed upload, so the object
+// is checked again here. Completing before the object exists answers 409
+// and can be retried; any other outcome uses the upload up.
+func (p *profilePhotoService) CompleteUpload(ctx context.Context, userID int64, uploadID string) (*entity.ProfilePhotoEntity, error) {
+	key := photoUploadPrefix + uploadID
+
+	jsonState, err := p.redis.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			err = errors.New("404")
+		}
+		log.Errorf("[ProfilePhotoService-1] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	state := entity.PhotoUploadStateEntity{}
+	if err = json.Unmarshal([]byte(jsonState), &state); err != nil {
+		log.Errorf("[ProfilePhotoService-2] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	if state.UserID != userID {
+		err = errors.New("404")
+		log.Errorf("[ProfilePhotoService-3] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	info, err := p.storage.Stat(ctx, state.Path)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err = errors.New("409")
+		}
+		log.Errorf("[ProfilePhotoService-4] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	// Deleting the key claims the upload, so a second request racing this
+	// one cannot attach it again.
+	if deleted, err := p.redis.Del(ctx, key).Result(); err != nil || deleted == 0 {
+		if err == nil {
+			err = errors.New("404")
+		}
+		log.Errorf("[ProfilePhotoService-5] CompleteUpload: %v", err)
+		return nil, err
+	}
+	defer p.removeFiles(ctx, []string{state.Path})
+
+	if info.Size > state.MaxSize {
+		err = errors.New("413")
+		log.Errorf("[ProfilePhotoService-6] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	user, err := p.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Errorf("[ProfilePhotoService-7] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	reader, err := p.storage.Download(ctx, state.Path)
+	if err != nil {
+		log.Errorf("[ProfilePhotoService-8] CompleteUpload: %v", err)
+		return nil, err
+	}
+	defer reader.Close()
+
+	// Read one byte past the limit in case the object grew after Stat.
+	data, err := io.ReadAll(io.LimitReader(reader, state.MaxSize+1))
+	if err != nil {
+		log.Errorf("[ProfilePhotoService-9] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	if int64(len(data)) > state.MaxSize {
+		err = errors.New("413")
+		log.Errorf("[ProfilePhotoService-10] CompleteUpload: %v", err)
+		return nil, err
+	}
+
+	return p.attach(ctx, user, data)
+}
+
+// attach processes data into every variant and makes it the profile photo
+// of user. All variants of one photo are stored side by side as
+// public/uploads/users/<id>/<uuid>/<variant>.<ext>. The previous photo is
+// removed once the new one is saved.
+func (p *profilePhotoService) attach(ctx context.Context, user *entity.UserEntity, data []byte) (*entity.ProfilePhotoEntity, error) {
+	outputs, err := imaging.Process(data, p.cfg.Image.MaxPixels, p.variants)
+	if err != nil {
+		log.Errorf("[ProfilePhotoService-1] attach: %v", err)
+		switch {
+		case errors.Is(err, imaging.ErrUnsupported):
+			return nil, errors.New("415")
+		case errors.Is(err, imaging.ErrTooLarge):
+			return nil, errors.New("413")
+		}
+		return nil, errors.New("422")
+	}
+
+	dir := fmt.Sprintf("public/uploads/users/%d/%s", user.ID, uuid.New().String())
+	photo := entity.ProfilePhotoEntity{}
+	uploaded := []string{}
+
+	for _, output := range outputs {
+		filePath := path.Join(dir, output.Name+output.Extension)
+
+		url, err := p.storage.UploadFile(ctx, filePath, bytes.NewReader(output.Data), output.ContentType)
+		if err != nil {
+			log.Errorf("[ProfilePhotoService-2] attach: %v", err)
+			p.removeFiles(ctx, uploaded)
+			return nil, err
+		}
+		uploaded = append(uploaded, filePath)
+
+		switch output.Name {
+		case photoVariantOriginal:
+			photo.URL, photo.Path = url, filePath
+		case photoVariantMedium:
+			photo.MediumURL = url
+		case photoVariantThumbnail:
+			photo.ThumbnailURL = url
+		}
+	}
+
+	if err = p.repo.UpdatePhoto(ctx, user.ID, photo); err != nil {
+		log.Errorf("[ProfilePhotoService-3] attach: %v", err)
+		p.removeFiles(ctx, uploaded)
+		return nil, err
+	}
+
+	p.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionProfileUpdated,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   user.ID,
+		Changes:    auditChanges{}.add("photo", user.Photo, photo.URL),
+	})
+
+	if user.PhotoPath != "" {
+		p.removeFiles(ctx, p.variantPaths(user.PhotoPath))
+	}
+
+	return &photo, nil
+}
+
+// DeleteFiles implements ProfilePhotoServiceInterface.
+// It deletes every variant of the photo stored at photoPath, for callers
+// that must not leave the files behind.
+func (p *profilePhotoService) DeleteFiles(ctx context.Context, photoPath string) error {
+	if photoPath == "" {
+		return nil
+	}
+
+	if err := p.storage.DeleteFiles(ctx, p.variantPaths(photoPath)...); err != nil {
+		log.Errorf("[ProfilePhotoService-1] DeleteFiles: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// variantPaths lists every variant stored next to originalPath.
+func (p *profilePhotoService) variantPaths(originalPath string) []string {
+	dir, ext := path.Dir(originalPath), path.Ext(originalPath)
+
+	paths := []string{}
+	for _, variant := range p.variants {
+		paths = append(paths, path.Join(dir, variant.Name+ext))
+	}
+
+	return paths
+}
+
+// removeFiles is best effort, a leftover file only costs storage.
+func (p *profilePhotoService) removeFiles(ctx context.Context, paths []string) {
+	if err := p.storage.DeleteFiles(ctx, paths...); err != nil {
+		log.Errorf("[ProfilePhotoService-1] removeFiles: %v", err)
+	}
+}
+
+func NewProfilePhotoService(cfg *config.Config, repo repository.UserRepositoryInterface, storageHandler storage.StorageInterface, audit AuditServiceInterface) ProfilePhotoServiceInterface {
+	return &profilePhotoService{
+		cfg:     cfg,
+		repo:    repo,
+		storage: storageHandler,
+		audit:   audit,
+		redis:   config.NewRedisClient(),
+		variants: []imaging.Variant{
+			{Name: photoVariantOriginal, Size: cfg.Image.OriginalSize},
+			{Name: photoVariantMedium, Size: cfg.Image.MediumSize},
+			{Name: photoVariantThumbnail, Size: cfg.Image.ThumbnailSize, Square: true},
+		},
+	}
+}
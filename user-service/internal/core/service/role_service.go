@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/labstack/gommon/log"
+
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+)
+
+// protectedRoles are seeded roles the service relies on (sign up assigns
+// Customer, the seeded admin uses Super Admin), so they cannot be renamed
+// or deleted through the API.
+var protectedRoles = map[string]bool{
+	"Super Admin": true,
+	"Customer":    true,
+}
+
+type RoleServiceInterface interface {
+	GetAll(ctx context.Context, search string) ([]entity.RoleEntity, error)
+	GetByID(ctx context.Context, id int64) (*entity.RoleEntity, error)
+	Create(ctx context.Context, req entity.RoleEntity) error
+	Update(ctx context.Context, req entity.RoleEntity) error
+	Delete(ctx context.Context, id int64) error
+	AssignRoleToUser(ctx context.Context, userID, roleID int64) error
+	UnassignRoleFromUser(ctx context.Context, userID, roleID int64) error
+	UpdateTwoFactorRequirement(ctx context.Context, id int64, required bool) error
+}
+
+type roleService struct {
+	repo  repository.RoleRepositoryI
+	audit AuditServiceInterface
+}
+
+// GetAll implements RoleServiceInterface.
+func (r *roleService) GetAll(ctx context.Context, search string) ([]entity.RoleEntity, error) {
+	return r.repo.GetAll(ctx, search)
+}
+
+// GetByID implements RoleServiceInterface.
+func (r *roleService) GetByID(ctx context.Context, id int64) (*entity.RoleEntity, error) {
+	return r.repo.GetByID(ctx, id)
+}
+
+// Create implements RoleServiceInterface.
+func (r *roleService) Create(ctx context.Context, req entity.RoleEntity) error {
+	roleID, err := r.repo.Create(ctx, req)
+	if err != nil {
+		log.Errorf("[RoleService-1] Create: %v", err)
+		return err
+	}
+
+	r.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionRoleCreated,
+		TargetType: entity.AuditTargetRole,
+		TargetID:   roleID,
+		Metadata:   map[string]interface{}{"name": req.Name},
+	})
+
+	return nil
+}
+
+// Update implements RoleServiceInterface.
+func (r *roleService) Update(ctx context.Context, req entity.RoleEntity) error {
+	role, err := r.repo.GetByID(ctx, req.ID)
+	if err != nil {
+		log.Errorf("[RoleService-1] Update: %v", err)
+		return err
+	}
+
+	if protectedRoles[role.Name] {
+		err = errors.New("403")
+		log.Errorf("[RoleService-2] Update: %v", err)
+		return err
+	}
+
+	if err = r.repo.Update(ctx, req); err != nil {
+		log.Errorf("[RoleService-3] Update: %v", err)
+		return err
+	}
+
+	r.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionRoleUpdated,
+		TargetType: entity.AuditTargetRole,
+		TargetID:   role.ID,
+		Changes:    auditChanges{}.add("name", role.Name, req.Name),
+	})
+
+	return nil
+}
+
+// Delete implements RoleServiceInterface.
+func (r *roleService) Delete(ctx context.Context, id int64) error {
+	role, err := r.repo.GetByID(ctx, id)
+	if err != nil {
+		log.Errorf("[RoleService-1] Delete: %v", err)
+		return err
+	}
+
+	if protectedRoles[role.Name] {
+		err = errors.New("403")
+		log.Errorf("[RoleService-2] Delete: %v", err)
+		return err
+	}
+
+	if err = r.repo.Delete(ctx, id); err != nil {
+		log.Errorf("[RoleService-3] Delete: %v", err)
+		return err
+	}
+
+	r.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionRoleDeleted,
+		TargetType: entity.AuditTargetRole,
+		TargetID:   role.ID,
+		Metadata:   map[string]interface{}{"name": role.Name},
+	})
+
+	return nil
+}
+
+// AssignRoleToUser implements RoleServiceInterface.
+func (r *roleService) AssignRoleToUser(ctx context.Context, userID, roleID int64) error {
+	if err := r.repo.AssignRoleToUser(ctx, userID, roleID); err != nil {
+		log.Errorf("[RoleService-1] AssignRoleToUser: %v", err)
+		return err
+	}
+
+	r.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionRoleAssigned,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   userID,
+		Metadata:   map[string]interface{}{"role_id": roleID},
+	})
+
+	return nil
+}
+
+// UnassignRoleFromUser implements RoleServiceInterface.
+func (r *roleService) UnassignRoleFromUser(ctx context.Context, userID, roleID int64) error {
+	if err := r.repo.UnassignRoleFromUser(ctx, userID, roleID); err != nil {
+		log.Errorf("[RoleService-1] UnassignRoleFromUser: %v", err)
+		return err
+	}
+
+	r.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionRoleUnassigned,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   userID,
+		Metadata:   map[string]interface{}{"role_id": roleID},
+	})
+
+	return nil
+}
+
+// UpdateTwoFactorRequirement implements RoleServiceInterface.
+func (r *roleService) UpdateTwoFactorRequirement(ctx context.Context, id int64, required bool) error {
+	role, err := r.repo.GetByID(ctx, id)
+	if err != nil {
+		log.Errorf("[RoleService-1] UpdateTwoFactorRequirement: %v", err)
+		return err
+	}
+
+	if err = r.repo.UpdateTwoFactorRequirement(ctx, id, required); err != nil {
+		log.Errorf("[RoleService-2] UpdateTwoFactorRequirement: %v", err)
+		return err
+	}
+
+	r.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionRoleTwoFactorUpdated,
+		TargetType: entity.AuditTargetRole,
+		TargetID:   role.ID,
+		Changes:    auditChanges{}.add("require_two_factor", role.RequireTwoFactor, required),
+	})
+
+	return nil
+}
+
+func NewRoleService(repo repository.RoleRepositoryI, audit AuditServiceInterface) RoleServiceInterface {
+	return &roleService{
+		repo:  repo,
+		audit: audit,
+	}
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/core/domain/entity"
+)
+
+const (
+	refreshTokenPrefix     = "refresh_token:"
+	refreshTokenUsedPrefix = "refresh_token_used:"
+	refreshFamilyPrefix    = "refresh_family:"
+	userSessionsPrefix     = "user_sessions:"
+)
+
+// SessionServiceInterface manages access-token sessions and the refresh
+// tokens that rotate them. Every sign-in starts a token family; each refresh
+// consumes the presented refresh token and issues a new pair in the same
+// family. Presenting an already consumed refresh token revokes the family.
+//
+// A token family is what users see as a session: its ID is the session ID
+// and families are indexed per user so they can be listed and revoked.
+type SessionServiceInterface interface {
+	CreateSession(ctx context.Context, user entity.UserEntity, familyID string, client entity.SessionClientEntity) (*entity.SessionEntity, error)
+	ConsumeRefreshToken(ctx context.Context, refreshToken string) (*entity.RefreshTokenEntity, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	ListUserSessions(ctx context.Context, userID int64, currentFamilyID string) ([]entity.UserSessionEntity, error)
+	RevokeUserSession(ctx context.Context, userID int64, familyID string) error
+	RevokeAllUserSessions(ctx context.Context, userID int64, exceptFamilyID string) error
+}
+
+type sessionService struct {
+	redis      *redis.Client
+	jwtService JwtServiceInterface
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	audit      AuditServiceInterface
+}
+
+// CreateSession implements SessionServiceInterface.
+// An empty familyID starts a new token family.
+func (s *sessionService) CreateSession(ctx context.Context, user entity.UserEntity, familyID string, client entity.SessionClientEntity) (*entity.SessionEntity, error) {
+	now := time.Now()
+	family := entity.RefreshFamilyEntity{
+		UserID:    user.ID,
+		CreatedAt: now,
+	}
+
+	newFamily := familyID == ""
+	if newFamily {
+		familyID = uuid.New().String()
+	} else {
+		current, err := s.getFamily(ctx, familyID)
+		if err != nil {
+			log.Errorf("[SessionService-1] CreateSession: %v", err)
+			return nil, err
+		}
+
+		// A revoked family must not be brought back by a late rotation.
+		if current.Revoked {
+			err = errors.New("401")
+			log.Errorf("[SessionService-2] CreateSession: family %s revoked", familyID)
+			return nil, err
+		}
+
+		if current.AccessToken != "" {
+			if err = s.redis.Del(ctx, current.AccessToken).Err(); err != nil {
+				log.Errorf("[SessionService-3] CreateSession: %v", err)
+				return nil, err
+			}
+		}
+		family = *current
+	}
+
+	family.LastUsedAt = now
+	family.IPAddress = client.IPAddress
+	if client.UserAgent != "" {
+		family.UserAgent = client.UserAgent
+	}
+
+	accessToken, err := s.jwtService.GenerateToken(user.ID, user.Roles)
+	if err != nil {
+		log.Errorf("[SessionService-4] CreateSession: %v", err)
+		return nil, err
+	}
+
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		log.Errorf("[SessionService-5] CreateSession: %v", err)
+		return nil, err
+	}
+
+	sessionData := map[string]interface{}{
+		"user_id":    user.ID,
+		"email":      user.Email,
+		"name":       user.Name,
+		"roles":      user.Roles,
+		"logged_in":  true,
+		"created_at": time.Now().String(),
+		"token":      accessToken,
+		"family_id":  familyID,
+	}
+
+	jsonSession, err := json.Marshal(sessionData)
+	if err != nil {
+		log.Errorf("[SessionService-6] CreateSession: %v", err)
+		return nil, err
+	}
+
+	jsonRefresh, err := json.Marshal(entity.RefreshTokenEntity{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Errorf("[SessionService-7] CreateSession: %v", err)
+		return nil, err
+	}
+
+	family.AccessToken = accessToken
+	jsonFamily, err := json.Marshal(family)
+	if err != nil {
+		log.Errorf("[SessionService-8] CreateSession: %v", err)
+		return nil, err
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, accessToken, jsonSession, s.accessTTL)
+	pipe.Set(ctx, refreshTokenPrefix+hashToken(refreshToken), jsonRefresh, s.refreshTTL)
+	pipe.Set(ctx, refreshFamilyPrefix+familyID, jsonFamily, s.refreshTTL)
+	pipe.SAdd(ctx, userSessionsKey(user.ID), familyID)
+	pipe.Expire(ctx, userSessionsKey(user.ID), s.refreshTTL)
+	if _, err = pipe.Exec(ctx); err != nil {
+		log.Errorf("[SessionService-9] CreateSession: %v", err)
+		return nil, err
+	}
+
+	// Every sign in method ends here; refreshes reuse their family.
+	if newFamily {
+		s.audit.Record(ctx, entity.AuditLogEntity{
+			Action:     entity.AuditActionSignIn,
+			ActorID:    user.ID,
+			TargetType: entity.AuditTargetUser,
+			TargetID:   user.ID,
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+			Metadata:   map[string]interface{}{"session_id": familyID},
+		})
+	}
+
+	return &entity.SessionEntity{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		FamilyID:     familyID,
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// ConsumeRefreshToken implements SessionServiceInterface.
+func (s *sessionService) ConsumeRefreshToken(ctx context.Context, refreshToken string) (*entity.RefreshTokenEntity, error) {
+	hashed := hashToken(refreshToken)
+
+	raw, err := s.redis.Get(ctx, refreshTokenPrefix+hashed).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			err = errors.New("401")
+		}
+		log.Errorf("[SessionService-1] ConsumeRefreshToken: %v", err)
+		return nil, err
+	}
+
+	refresh := entity.RefreshTokenEntity{}
+	if err = json.Unmarshal([]byte(raw), &refresh); err != nil {
+		log.Errorf("[SessionService-2] ConsumeRefreshToken: %v", err)
+		return nil, err
+	}
+
+	family, err := s.getFamily(ctx, refresh.FamilyID)
+	if err != nil {
+		log.Errorf("[SessionService-3] ConsumeRefreshToken: %v", err)
+		return nil, err
+	}
+
+	if family.Revoked {
+		err = errors.New("401")
+		log.Errorf("[SessionService-4] ConsumeRefreshToken: family %s revoked", refresh.FamilyID)
+		return nil, err
+	}
+
+	firstUse, err := s.redis.SetNX(ctx, refreshTokenUsedPrefix+hashed, 1, s.refreshTTL).Result()
+	if err != nil {
+		log.Errorf("[SessionService-5] ConsumeRefreshToken: %v", err)
+		return nil, err
+	}
+
+	if !firstUse {
+		log.Warnf("[SessionService-6] ConsumeRefreshToken: refresh token reused, revoking family %s", refresh.FamilyID)
+		if err = s.RevokeFamily(ctx, refresh.FamilyID); err != nil {
+			log.Errorf("[SessionService-7] ConsumeRefreshToken: %v", err)
+			return nil, err
+		}
+		return nil, errors.New("401")
+	}
+
+	return &refresh, nil
+}
+
+// RevokeFamily implements SessionServiceInterface.
+func (s *sessionService) RevokeFamily(ctx context.Context, familyID string) error {
+	family, err := s.getFamily(ctx, familyID)
+	if err != nil {
+		log.Errorf("[SessionService-1] RevokeFamily: %v", err)
+		return err
+	}
+
+	if family.AccessToken != "" {
+		if err = s.redis.Del(ctx, family.AccessToken).Err(); err != nil {
+			log.Errorf("[SessionService-2] RevokeFamily: %v", err)
+			return err
+		}
+	}
+
+	family.Revoked = true
+	family.AccessToken = ""
+	jsonFamily, err := json.Marshal(family)
+	if err != nil {
+		log.Errorf("[SessionService-3] RevokeFamily: %v", err)
+		return err
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, refreshFamilyPrefix+familyID, jsonFamily, s.refreshTTL)
+	pipe.SRem(ctx, userSessionsKey(family.UserID), familyID)
+	if _, err = pipe.Exec(ctx); err != nil {
+		log.Errorf("[SessionService-4] RevokeFamily: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ListUserSessions implements SessionServiceInterface.
+func (s *sessionService) ListUserSessions(ctx context.Context, userID int64, currentFamilyID string) ([]entity.UserSessionEntity, error) {
+	familyIDs, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		log.Errorf("[SessionService-1] ListUserSessions: %v", err)
+		return nil, err
+	}
+
+	sessions := []entity.UserSessionEntity{}
+	for _, familyID := range familyIDs {
+		family, err := s.getFamily(ctx, familyID)
+		if err != nil || family.Revoked {
+			// Expired or revoked families are dropped from the index lazily.
+			if errRem := s.redis.SRem(ctx, userSessionsKey(userID), familyID).Err(); errRem != nil {
+				log.Errorf("[SessionService-2] ListUserSessions: %v", errRem)
+			}
+			continue
+		}
+
+		sessions = append(sessions, entity.UserSessionEntity{
+			ID:         familyID,
+			UserAgent:  family.UserAgent,
+			IPAddress:  family.IPAddress,
+			CreatedAt:  family.CreatedAt,
+			LastUsedAt: family.LastUsedAt,
+			Current:    familyID == currentFamilyID,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+// RevokeUserSession implements SessionServiceInterface.
+func (s *sessionService) RevokeUserSession(ctx context.Context, userID int64, familyID string) error {
+	family, err := s.getFamily(ctx, familyID)
+	if err != nil || family.UserID != userID || family.Revoked {
+		err = errors.New("404")
+		log.Errorf("[SessionService-1] RevokeUserSession: %v", err)
+		return err
+	}
+
+	return s.RevokeFamily(ctx, familyID)
+}
+
+// RevokeAllUserSessions implements SessionServiceInterface.
+// exceptFamilyID keeps the caller's own session alive; pass "" to revoke all.
+func (s *sessionService) RevokeAllUserSessions(ctx context.Context, userID int64, exceptFamilyID string) error {
+	familyIDs, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		log.Errorf("[SessionService-1] RevokeAllUserSessions: %v", err)
+		return err
+	}
+
+	for _, familyID := range familyIDs {
+		if familyID == exceptFamilyID {
+			continue
+		}
+
+		err = s.RevokeFamily(ctx, familyID)
+		if err == nil {
+			continue
+		}
+
+		if err.Error() != "401" {
+			log.Errorf("[SessionService-2] RevokeAllUserSessions: %v", err)
+			return err
+		}
+
+		// The family already expired, only its index entry is left.
+		if err = s.redis.SRem(ctx, userSessionsKey(userID), familyID).Err(); err != nil {
+			log.Errorf("[SessionService-3] RevokeAllUserSessions: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sessionService) getFamily(ctx context.Context, familyID string) (*entity.RefreshFamilyEntity, error) {
+	raw, err := s.redis.Get(ctx, refreshFamilyPrefix+familyID).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.New("401")
+		}
+		return nil, err
+	}
+
+	family := entity.RefreshFamilyEntity{}
+	if err = json.Unmarshal([]byte(raw), &family); err != nil {
+		return nil, err
+	}
+
+	return &family, nil
+}
+
+func userSessionsKey(userID int64) string {
+	return fmt.Sprintf("%s%d", userSessionsPrefix, userID)
+}
+
+func generateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken keeps raw refresh tokens out of Redis.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewSessionService(cfg *config.Config, jwtService JwtServiceInterface, audit AuditServiceInterface) SessionServiceInterface {
+	return &sessionService{
+		redis:      config.NewRedisClient(),
+		jwtService: jwtService,
+		audit:      audit,
+		accessTTL:  cfg.App.JwtAccessTokenTTL,
+		refreshTTL: cfg.App.JwtRefreshTokenTTL,
+	}
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/labstack/gommon/log"
+
+	"user-service/config"
+	"user-service/internal/adapter/repository"
+	"user-service/internal/core/domain/entity"
+	"user-service/utils/totp"
+)
+
+const (
+	twoFactorChallengePrefix = "two_factor_challenge:"
+	twoFactorUsedStepPrefix  = "two_factor_used:"
+	twoFactorFailPrefix      = "two_factor_fail:"
+
+	twoFactorChallengeTTL      = 5 * time.Minute
+	twoFactorChallengeAttempts = 5
+	// twoFactorUserAttempts limits wrong codes per user across challenges,
+	// since signing in with the password again yields a fresh challenge.
+	twoFactorUserAttempts  = 10
+	twoFactorRecoveryCodes = 10
+)
+
+// TwoFactorServiceInterface handles TOTP enrollment and the second sign-in
+// step. A password sign-in for a user with 2FA enabled, or whose role
+// requires it, yields a short-lived challenge token instead of a session;
+// the session is only created once the challenge is completed.
+type TwoFactorServiceInterface interface {
+	CreateChallenge(ctx context.Context, user entity.UserEntity) (*entity.SessionEntity, error)
+	VerifyChallenge(ctx context.Context, challengeToken, code string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error)
+	EnrollWithChallenge(ctx context.Context, challengeToken string) (*entity.TwoFactorEnrollmentEntity, error)
+	ConfirmWithChallenge(ctx context.Context, challengeToken, code string, client entity.SessionClientEntity) ([]string, *entity.UserEntity, *entity.SessionEntity, error)
+
+	Enroll(ctx context.Context, userID int64) (*entity.TwoFactorEnrollmentEntity, error)
+	Confirm(ctx context.Context, userID int64, code string) ([]string, error)
+	Disable(ctx context.Context, userID int64, code string) error
+}
+
+type twoFactorService struct {
+	repo           repository.TwoFactorRepositoryInterface
+	userRepo       repository.UserRepositoryInterface
+	sessionService SessionServiceInterface
+	redis          *redis.Client
+	issuer         string
+	lockoutTimeout time.Duration
+}
+
+// CreateChallenge implements TwoFactorServiceInterface.
+func (t *twoFactorService) CreateChallenge(ctx context.Context, user entity.UserEntity) (*entity.SessionEntity, error) {
+	challengeToken, err := generateOpaqueToken()
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] CreateChallenge: %v", err)
+		return nil, err
+	}
+
+	setup := !user.TwoFactorEnabled
+	key := twoFactorChallengePrefix + hashToken(challengeToken)
+
+	pipe := t.redis.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", user.ID, "setup", setup, "attempts", 0)
+	pipe.Expire(ctx, key, twoFactorChallengeTTL)
+	if _, err = pipe.Exec(ctx); err != nil {
+		log.Errorf("[TwoFactorService-2] CreateChallenge: %v", err)
+		return nil, err
+	}
+
+	return &entity.SessionEntity{
+		TwoFactorChallenge: challengeToken,
+		TwoFactorSetup:     setup,
+	}, nil
+}
+
+// VerifyChallenge implements TwoFactorServiceInterface.
+// Both TOTP codes and unused recovery codes are accepted.
+func (t *twoFactorService) VerifyChallenge(ctx context.Context, challengeToken, code string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	challenge, err := t.getChallenge(ctx, challengeToken)
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] VerifyChallenge: %v", err)
+		return nil, nil, err
+	}
+
+	if challenge.Setup {
+		err = errors.New("428")
+		log.Errorf("[TwoFactorService-2] VerifyChallenge: %v", err)
+		return nil, nil, err
+	}
+
+	if err = t.checkUserFailures(ctx, challenge.UserID); err != nil {
+		log.Errorf("[TwoFactorService-3] VerifyChallenge: %v", err)
+		return nil, nil, err
+	}
+
+	twoFactor, err := t.repo.GetTwoFactor(ctx, challenge.UserID)
+	if err != nil {
+		log.Errorf("[TwoFactorService-4] VerifyChallenge: %v", err)
+		return nil, nil, err
+	}
+
+	if err = t.verifyCode(ctx, twoFactor, code, true); err != nil {
+		log.Errorf("[TwoFactorService-5] VerifyChallenge: %v", err)
+		if err.Error() == "401" {
+			t.registerChallengeFailure(ctx, challengeToken, challenge.UserID)
+		}
+		return nil, nil, err
+	}
+
+	return t.completeChallenge(ctx, challengeToken, challenge.UserID, client)
+}
+
+// EnrollWithChallenge implements TwoFactorServiceInterface.
+// It lets a user whose role requires 2FA enroll before having a session.
+func (t *twoFactorService) EnrollWithChallenge(ctx context.Context, challengeToken string) (*entity.TwoFactorEnrollmentEntity, error) {
+	challenge, err := t.getChallenge(ctx, challengeToken)
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] EnrollWithChallenge: %v", err)
+		return nil, err
+	}
+
+	if !challenge.Setup {
+		err = errors.New("409")
+		log.Errorf("[TwoFactorService-2] EnrollWithChallenge: %v", err)
+		return nil, err
+	}
+
+	return t.Enroll(ctx, challenge.UserID)
+}
+
+// ConfirmWithChallenge implements TwoFactorServiceInterface.
+func (t *twoFactorService) ConfirmWithChallenge(ctx context.Context, challengeToken, code string, client entity.SessionClientEntity) ([]string, *entity.UserEntity, *entity.SessionEntity, error) {
+	challenge, err := t.getChallenge(ctx, challengeToken)
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] ConfirmWithChallenge: %v", err)
+		return nil, nil, nil, err
+	}
+
+	if !challenge.Setup {
+		err = errors.New("409")
+		log.Errorf("[TwoFactorService-2] ConfirmWithChallenge: %v", err)
+		return nil, nil, nil, err
+	}
+
+	if err = t.checkUserFailures(ctx, challenge.UserID); err != nil {
+		log.Errorf("[TwoFactorService-3] ConfirmWithChallenge: %v", err)
+		return nil, nil, nil, err
+	}
+
+	recoveryCodes, err := t.Confirm(ctx, challenge.UserID, code)
+	if err != nil {
+		log.Errorf("[TwoFactorService-4] ConfirmWithChallenge: %v", err)
+		if err.Error() == "401" {
+			t.registerChallengeFailure(ctx, challengeToken, challenge.UserID)
+		}
+		return nil, nil, nil, err
+	}
+
+	user, session, err := t.completeChallenge(ctx, challengeToken, challenge.UserID, client)
+	if err != nil {
+		log.Errorf("[TwoFactorService-5] ConfirmWithChallenge: %v", err)
+		return nil, nil, nil, err
+	}
+
+	return recoveryCodes, user, session, nil
+}
+
+// Enroll implements TwoFactorServiceInterface.
+// A new pending secret replaces any previous unconfirmed one.
+func (t *twoFactorService) Enroll(ctx context.Context, userID int64) (*entity.TwoFactorEnrollmentEntity, error) {
+	twoFactor, err := t.repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] Enroll: %v", err)
+		return nil, err
+	}
+
+	if twoFactor.Enabled {
+		err = errors.New("409")
+		log.Errorf("[TwoFactorService-2] Enroll: %v", err)
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Errorf("[TwoFactorService-3] Enroll: %v", err)
+		return nil, err
+	}
+
+	if err = t.repo.SaveSecret(ctx, userID, secret); err != nil {
+		log.Errorf("[TwoFactorService-4] Enroll: %v", err)
+		return nil, err
+	}
+
+	return &entity.TwoFactorEnrollmentEntity{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(t.issuer, twoFactor.Email, secret),
+	}, nil
+}
+
+// Confirm implements TwoFactorServiceInterface.
+// It returns the plain recovery codes, which are only stored hashed.
+func (t *twoFactorService) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	twoFactor, err := t.repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] Confirm: %v", err)
+		return nil, err
+	}
+
+	if twoFactor.Enabled {
+		err = errors.New("409")
+		log.Errorf("[TwoFactorService-2] Confirm: %v", err)
+		return nil, err
+	}
+
+	if twoFactor.Secret == "" {
+		err = errors.New("404")
+		log.Errorf("[TwoFactorService-3] Confirm: %v", err)
+		return nil, err
+	}
+
+	if err = t.verifyCode(ctx, twoFactor, code, false); err != nil {
+		log.Errorf("[TwoFactorService-4] Confirm: %v", err)
+		return nil, err
+	}
+
+	recoveryCodes := make([]string, 0, twoFactorRecoveryCodes)
+	hashes := make([]string, 0, twoFactorRecoveryCodes)
+	for i := 0; i < twoFactorRecoveryCodes; i++ {
+		recoveryCode, err := generateRecoveryCode()
+		if err != nil {
+			log.Errorf("[TwoFactorService-5] Confirm: %v", err)
+			return nil, err
+		}
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	if err = t.repo.Enable(ctx, userID, hashes); err != nil {
+		log.Errorf("[TwoFactorService-6] Confirm: %v", err)
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Disable implements TwoFactorServiceInterface.
+// Users whose role requires 2FA cannot turn it off.
+func (t *twoFactorService) Disable(ctx context.Context, userID int64, code string) error {
+	user, err := t.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] Disable: %v", err)
+		return err
+	}
+
+	if user.TwoFactorRequired {
+		err = errors.New("403")
+		log.Errorf("[TwoFactorService-2] Disable: %v", err)
+		return err
+	}
+
+	twoFactor, err := t.repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		log.Errorf("[TwoFactorService-3] Disable: %v", err)
+		return err
+	}
+
+	if !twoFactor.Enabled {
+		err = errors.New("404")
+		log.Errorf("[TwoFactorService-4] Disable: %v", err)
+		return err
+	}
+
+	if err = t.checkUserFailures(ctx, userID); err != nil {
+		log.Errorf("[TwoFactorService-5] Disable: %v", err)
+		return err
+	}
+
+	if err = t.verifyCode(ctx, twoFactor, code, true); err != nil {
+		log.Errorf("[TwoFactorService-6] Disable: %v", err)
+		if err.Error() == "401" {
+			t.registerUserFailure(ctx, userID)
+		}
+		return err
+	}
+
+	if err = t.redis.Del(ctx, twoFactorFailPrefix+strconv.FormatInt(userID, 10)).Err(); err != nil {
+		log.Errorf("[TwoFactorService-7] Disable: %v", err)
+	}
+
+	return t.repo.Disable(ctx, userID)
+}
+
+// verifyCode accepts a TOTP code once per time step, or an unused recovery
+// code when allowRecovery is set. It returns 401 for a wrong code.
+func (t *twoFactorService) verifyCode(ctx context.Context, twoFactor *entity.TwoFactorEntity, code string, allowRecovery bool) error {
+	if step, ok := totp.Validate(twoFactor.Secret, code, time.Now(), 1); ok {
+		key := fmt.Sprintf("%s%d:%d", twoFactorUsedStepPrefix, twoFactor.UserID, step)
+		firstUse, err := t.redis.SetNX(ctx, key, 1, 3*totp.Period*time.Second).Result()
+		if err != nil {
+			return err
+		}
+
+		if !firstUse {
+			return errors.New("401")
+		}
+		return nil
+	}
+
+	if !allowRecovery {
+		return errors.New("401")
+	}
+
+	return t.repo.UseRecoveryCode(ctx, twoFactor.UserID, hashToken(normalizeRecoveryCode(code)))
+}
+
+func (t *twoFactorService) getChallenge(ctx context.Context, challengeToken string) (*entity.TwoFactorChallengeEntity, error) {
+	values, err := t.redis.HGetAll(ctx, twoFactorChallengePrefix+hashToken(challengeToken)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, errors.New("401")
+	}
+
+	userID, err := strconv.ParseInt(values["user_id"], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TwoFactorChallengeEntity{
+		UserID: userID,
+		Setup:  values["setup"] == "1",
+	}, nil
+}
+
+// checkUserFailures returns 423 once the user has entered too many wrong
+// codes. Only a correct code clears the count, a correct password does
+// not.
+func (t *twoFactorService) checkUserFailures(ctx context.Context, userID int64) error {
+	failures, err := t.redis.Get(ctx, twoFactorFailPrefix+strconv.FormatInt(userID, 10)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	if failures >= twoFactorUserAttempts {
+		return errors.New("423")
+	}
+
+	return nil
+}
+
+// registerChallengeFailure drops the challenge after too many wrong codes,
+// forcing the user to sign in with the password again. Every failure also
+// counts towards the user's limit, which locks out further codes for the
+// lockout duration.
+func (t *twoFactorService) registerChallengeFailure(ctx context.Context, challengeToken string, userID int64) {
+	t.registerUserFailure(ctx, userID)
+
+	key := twoFactorChallengePrefix + hashToken(challengeToken)
+
+	attempts, err := t.redis.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		log.Errorf("[TwoFactorService-1] registerChallengeFailure: %v", err)
+		return
+	}
+
+	if attempts >= twoFactorChallengeAttempts {
+		if err = t.redis.Del(ctx, key).Err(); err != nil {
+			log.Errorf("[TwoFactorService-2] registerChallengeFailure: %v", err)
+		}
+	}
+}
+
+// registerUserFailure counts a wrong code against the user, whichever way
+// it was entered, towards the lockout checked by checkUserFailures.
+func (t *twoFactorService) registerUserFailure(ctx context.Context, userID int64) {
+	failKey := twoFactorFailPrefix + strconv.FormatInt(userID, 10)
+
+	pipe := t.redis.TxPipeline()
+	pipe.Incr(ctx, failKey)
+	pipe.Expire(ctx, failKey, t.lockoutTimeout)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Errorf("[TwoFactorService-1] registerUserFailure: %v", err)
+	}
+}
+
+func (t *twoFactorService) completeChallenge(ctx context.Context, challengeToken string, userID int64, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	deleted, err := t.redis.Del(ctx, twoFactorChallengePrefix+hashToken(challengeToken)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Another request completed the same challenge first.
+	if deleted == 0 {
+		return nil, nil, errors.New("401")
+	}
+
+	if err = t.redis.Del(ctx, twoFactorFailPrefix+strconv.FormatInt(userID, 10)).Err(); err != nil {
+		log.Errorf("[TwoFactorService-1] completeChallenge: %v", err)
+	}
+
+	user, err := t.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The user may have been suspended since the challenge was issued.
+	if user.IsSuspended {
+		return nil, nil, errors.New("403")
+	}
+
+	session, err := t.sessionService.CreateSession(ctx, *user, "", client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, session, nil
+}
+
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func NewTwoFactorService(cfg *config.Config, repo repository.TwoFactorRepositoryInterface, userRepo repository.UserRepositoryInterface, sessionService SessionServiceInterface) TwoFactorServiceInterface {
+	return &twoFactorService{
+		repo:           repo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		redis:          config.NewRedisClient(),
+		issuer:         cfg.Security.TwoFactorIssuer,
+		lockoutTimeout: cfg.Security.LoginLockoutDuration,
+	}
+}
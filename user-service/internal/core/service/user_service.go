@@ -2,9 +2,9 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,29 +14,310 @@ import (
 	"user-service/internal/adapter/message"
 	"user-service/internal/adapter/repository"
 	"user-service/internal/core/domain/entity"
-	"user-service/utils/conv"
+	"user-service/utils/password"
 )
 
 type UserServiceInterface interface {
-	SignIn(ctx context.Context, req entity.UserEntity) (*entity.UserEntity, string, error)
+	SignIn(ctx context.Context, req entity.UserEntity, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error)
+	RefreshToken(ctx context.Context, refreshToken string, client entity.SessionClientEntity) (*entity.SessionEntity, error)
 	CreateUserAccount(ctx context.Context, req entity.UserEntity) error
 	ForgotPassword(ctx context.Context, req entity.UserEntity) error
-	VerifyToken(ctx context.Context, token string) (*entity.UserEntity, error)
+	ResendVerification(ctx context.Context, email string) error
+	VerifyToken(ctx context.Context, token string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error)
 	UpdatePassword(ctx context.Context, req entity.UserEntity) error
+	ChangePassword(ctx context.Context, userID int64, familyID, currentPassword, newPassword string) error
 	GetProfileUser(ctx context.Context, userID int64) (*entity.UserEntity, error)
 	UpdateDataUser(ctx context.Context, req entity.UserEntity) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+
+	// Admin
+	GetAllUser(ctx context.Context, query entity.QueryUserEntity) ([]entity.UserEntity, int64, int64, error)
+	GetNearbyUsers(ctx context.Context, query entity.QueryNearbyUserEntity) ([]entity.NearbyUserEntity, error)
+	GetUserDetailByID(ctx context.Context, userID int64) (*entity.UserEntity, error)
+	SuspendUser(ctx context.Context, userID int64) error
+	UnsuspendUser(ctx context.Context, userID int64) error
+	DeleteUser(ctx context.Context, userID int64) error
+	ForcePasswordReset(ctx context.Context, userID int64) error
+	UnlockUser(ctx context.Context, userID int64) error
 }
 
 type userService struct {
-	repo       repository.UserRepositoryInterface
-	cfg        *config.Config
-	jwtService JwtServiceInterface
-	repoToken  repository.VerificationTokenRepositoryInterface
+	repo             repository.UserRepositoryInterface
+	cfg              *config.Config
+	sessionService   SessionServiceInterface
+	repoToken        repository.VerificationTokenRepositoryInterface
+	repoOutbox       repository.OutboxRepositoryInterface
+	loginGuard       LoginGuardServiceInterface
+	twoFactorService TwoFactorServiceInterface
+	passwordPolicy   PasswordPolicyServiceInterface
+	hasher           password.Hasher
+	audit            AuditServiceInterface
+}
+
+// GetAllUser implements UserServiceInterface.
+func (u *userService) GetAllUser(ctx context.Context, query entity.QueryUserEntity) ([]entity.UserEntity, int64, int64, error) {
+	return u.repo.GetAllUser(ctx, query)
+}
+
+// GetNearbyUsers implements UserServiceInterface.
+func (u *userService) GetNearbyUsers(ctx context.Context, query entity.QueryNearbyUserEntity) ([]entity.NearbyUserEntity, error) {
+	return u.repo.GetNearbyUsers(ctx, query)
+}
+
+// GetUserDetailByID implements UserServiceInterface.
+func (u *userService) GetUserDetailByID(ctx context.Context, userID int64) (*entity.UserEntity, error) {
+	return u.repo.GetUserDetailByID(ctx, userID)
+}
+
+// SuspendUser implements UserServiceInterface.
+func (u *userService) SuspendUser(ctx context.Context, userID int64) error {
+	if err := u.repo.UpdateSuspendStatus(ctx, userID, true); err != nil {
+		log.Errorf("[UserService-1] SuspendUser: %v", err)
+		return err
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionUserSuspended,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   userID,
+	})
+
+	return u.sessionService.RevokeAllUserSessions(ctx, userID, "")
+}
+
+// UnsuspendUser implements UserServiceInterface.
+func (u *userService) UnsuspendUser(ctx context.Context, userID int64) error {
+	if err := u.repo.UpdateSuspendStatus(ctx, userID, false); err != nil {
+		log.Errorf("[UserService-1] UnsuspendUser: %v", err)
+		return err
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionUserUnsuspended,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   userID,
+	})
+
+	return nil
+}
+
+// DeleteUser implements UserServiceInterface.
+func (u *userService) DeleteUser(ctx context.Context, userID int64) error {
+	if err := u.repo.SoftDeleteUser(ctx, userID); err != nil {
+		log.Errorf("[UserService-1] DeleteUser: %v", err)
+		return err
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionUserDeleted,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   userID,
+	})
+
+	return u.sessionService.RevokeAllUserSessions(ctx, userID, "")
+}
+
+// ForcePasswordReset implements UserServiceInterface.
+// The current password is replaced with a random one so it stops working
+// immediately, then a reset link is sent to the user's email and every
+// session is signed out.
+func (u *userService) ForcePasswordReset(ctx context.Context, userID int64) error {
+	user, err := u.repo.GetUserDetailByID(ctx, userID)
+	if err != nil {
+		log.Errorf("[UserService-1] ForcePasswordReset: %v", err)
+		return err
+	}
+
+	pwd, err := u.hasher.Hash(uuid.New().String())
+	if err != nil {
+		log.Errorf("[UserService-2] ForcePasswordReset: %v", err)
+		return err
+	}
+
+	err = u.repo.UpdatePasswordByID(ctx, entity.UserEntity{ID: user.ID, Password: pwd})
+	if err != nil {
+		log.Errorf("[UserService-3] ForcePasswordReset: %v", err)
+		return err
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionPasswordResetForced,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+
+	token := uuid.New().String()
+	urlForgot := fmt.Sprintf("%s/forgot-password?token=%s", u.cfg.App.UrlForgotPassword, token)
+	messageParam := fmt.Sprintf("your password has been reset by an administrator, please click link below to set a new password: %v", urlForgot)
+	resetEmail, err := message.NotificationEvent(user.Email, messageParam)
+	if err != nil {
+		log.Errorf("[UserService-4] ForcePasswordReset: %v", err)
+		return err
+	}
+
+	reqEntity := entity.VerificationTokenEntity{
+		UserID:    user.ID,
+		Token:     token,
+		TokenType: entity.TokenTypeForgotPassword,
+	}
+
+	err = u.repoToken.CreateVerificationToken(ctx, reqEntity, resetEmail)
+	if err != nil {
+		log.Errorf("[UserService-5] ForcePasswordReset: %v", err)
+		return err
+	}
+
+	if err = u.sessionService.RevokeAllUserSessions(ctx, user.ID, ""); err != nil {
+		log.Errorf("[UserService-6] ForcePasswordReset: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// UnlockUser implements UserServiceInterface.
+func (u *userService) UnlockUser(ctx context.Context, userID int64) error {
+	user, err := u.repo.GetUserDetailByID(ctx, userID)
+	if err != nil {
+		log.Errorf("[UserService-1] UnlockUser: %v", err)
+		return err
+	}
+
+	if err = u.loginGuard.Unlock(ctx, user.Email); err != nil {
+		log.Errorf("[UserService-2] UnlockUser: %v", err)
+		return err
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionUserUnlocked,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+
+	return nil
 }
 
 // UpdateDataUser implements UserServiceInterface.
+// A new email is not applied right away: it is kept as pending until the
+// link sent to it is opened, and the current address is told about it.
 func (u *userService) UpdateDataUser(ctx context.Context, req entity.UserEntity) error {
-	return u.repo.UpdateDataUser(ctx, req)
+	// Emails are unique regardless of case and stored lower-cased.
+	req.Email = normalizeEmail(req.Email)
+
+	user, err := u.repo.GetUserByID(ctx, req.ID)
+	if err != nil {
+		log.Errorf("[UserService-1] UpdateDataUser: %v", err)
+		return err
+	}
+
+	changeEmail := !strings.EqualFold(req.Email, user.Email) && !strings.EqualFold(req.Email, user.PendingEmail)
+	if changeEmail {
+		exists, err := u.repo.EmailExists(ctx, req.Email, user.ID)
+		if err != nil {
+			log.Errorf("[UserService-2] UpdateDataUser: %v", err)
+			return err
+		}
+		if exists {
+			err = errors.New("409")
+			log.Errorf("[UserService-3] UpdateDataUser: %v", err)
+			return err
+		}
+
+		if err = checkTokenCooldown(ctx, u.repoToken, user.ID, entity.TokenTypeEmailChange, u.cfg.Security.TokenResendCooldown); err != nil {
+			log.Errorf("[UserService-4] UpdateDataUser: %v", err)
+			return err
+		}
+	}
+
+	if err = u.repo.UpdateDataUser(ctx, req); err != nil {
+		log.Errorf("[UserService-5] UpdateDataUser: %v", err)
+		return err
+	}
+
+	changes := auditChanges{}.
+		add("name", user.Name, req.Name).
+		add("address", user.Address, req.Address).
+		add("lat", user.Lat, req.Lat).
+		add("lng", user.Lng, req.Lng).
+		add("phone", user.Phone, req.Phone)
+	if changeEmail {
+		changes.add("pending_email", user.PendingEmail, req.Email)
+	}
+	if len(changes) > 0 {
+		u.audit.Record(ctx, entity.AuditLogEntity{
+			Action:     entity.AuditActionProfileUpdated,
+			TargetType: entity.AuditTargetUser,
+			TargetID:   user.ID,
+			Changes:    changes,
+		})
+	}
+
+	if !changeEmail {
+		return nil
+	}
+
+	if err = u.repo.SetPendingEmail(ctx, user.ID, req.Email); err != nil {
+		log.Errorf("[UserService-6] UpdateDataUser: %v", err)
+		return err
+	}
+
+	token := uuid.New().String()
+	urlConfirm := fmt.Sprintf("%s/confirm-email?token=%s", u.cfg.App.UrlVerifyAccount, token)
+	messageParam := fmt.Sprintf("please confirm your new email address with click link below: %v", urlConfirm)
+	confirmEmail, err := message.NotificationEvent(req.Email, messageParam)
+	if err != nil {
+		log.Errorf("[UserService-7] UpdateDataUser: %v", err)
+		return err
+	}
+
+	messageParam = fmt.Sprintf("a request was made to change the email of your account to %s. If this wasn't you, please reset your password right away.", req.Email)
+	noticeEmail, err := message.NotificationEvent(user.Email, messageParam)
+	if err != nil {
+		log.Errorf("[UserService-8] UpdateDataUser: %v", err)
+		return err
+	}
+
+	err = u.repoToken.CreateVerificationToken(ctx, entity.VerificationTokenEntity{
+		UserID:    user.ID,
+		Token:     token,
+		TokenType: entity.TokenTypeEmailChange,
+	}, confirmEmail, noticeEmail)
+	if err != nil {
+		log.Errorf("[UserService-9] UpdateDataUser: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange implements UserServiceInterface.
+func (u *userService) ConfirmEmailChange(ctx context.Context, token string) error {
+	verifyToken, err := u.repoToken.ConsumeToken(ctx, token, entity.TokenTypeEmailChange)
+	if err != nil {
+		log.Errorf("[UserService-1] ConfirmEmailChange: %v", err)
+		return err
+	}
+
+	user, err := u.repo.GetUserByID(ctx, verifyToken.UserID)
+	if err != nil {
+		log.Errorf("[UserService-2] ConfirmEmailChange: %v", err)
+		return err
+	}
+
+	if err = u.repo.ConfirmPendingEmail(ctx, user.ID); err != nil {
+		log.Errorf("[UserService-3] ConfirmEmailChange: %v", err)
+		return err
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionEmailChanged,
+		ActorID:    user.ID,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   user.ID,
+		Changes:    auditChanges{}.add("email", user.Email, user.PendingEmail),
+	})
+
+	return nil
 }
 
 // GetProfileUser implements UserServiceInterface.
@@ -45,81 +326,153 @@ func (u *userService) GetProfileUser(ctx context.Context, userID int64) (*entity
 }
 
 // UpdatePassword implements UserServiceInterface.
+// The reset token is consumed, so the same link cannot be used twice.
 func (u *userService) UpdatePassword(ctx context.Context, req entity.UserEntity) error {
-
 	token, err := u.repoToken.GetDataByToken(ctx, req.Token)
+	if err == nil && token.TokenType != entity.TokenTypeForgotPassword {
+		err = errors.New("401")
+	}
 	if err != nil {
 		log.Errorf("[UserService-1] UpdatePassword: %v", err)
 		return err
 	}
 
-	if token.TokenType != "forgot_password" {
-		err = errors.New("401")
+	user, err := u.repo.GetUserByID(ctx, token.UserID)
+	if err != nil {
 		log.Errorf("[UserService-2] UpdatePassword: %v", err)
 		return err
 	}
 
-	pwd, err := conv.HashPassword(req.Password)
-	if err != nil {
+	if err = u.passwordPolicy.Validate(ctx, req.Password, *user); err != nil {
 		log.Errorf("[UserService-3] UpdatePassword: %v", err)
 		return err
 	}
 
+	pwd, err := u.hasher.Hash(req.Password)
+	if err != nil {
+		log.Errorf("[UserService-4] UpdatePassword: %v", err)
+		return err
+	}
+
+	if _, err = u.repoToken.ConsumeToken(ctx, req.Token, entity.TokenTypeForgotPassword); err != nil {
+		log.Errorf("[UserService-5] UpdatePassword: %v", err)
+		return err
+	}
+
+	req.ID = user.ID
 	req.Password = pwd
 	err = u.repo.UpdatePasswordByID(ctx, req)
 	if err != nil {
-		log.Errorf("[UserService-4] UpdatePassword: %v", err)
+		log.Errorf("[UserService-6] UpdatePassword: %v", err)
 		return err
 	}
 
+	if err = u.passwordPolicy.Remember(ctx, user.ID, user.Password); err != nil {
+		log.Errorf("[UserService-7] UpdatePassword: %v", err)
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionPasswordReset,
+		ActorID:    user.ID,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+
 	return nil
 }
 
-// VerifyToken implements UserServiceInterface.
-func (u *userService) VerifyToken(ctx context.Context, token string) (*entity.UserEntity, error) {
-	verifyToken, err := u.repoToken.GetDataByToken(ctx, token)
+// ChangePassword implements UserServiceInterface.
+// Every other session is signed out so a leaked password stops working
+// everywhere; the session that made the change (familyID) stays valid.
+func (u *userService) ChangePassword(ctx context.Context, userID int64, familyID, currentPassword, newPassword string) error {
+	user, err := u.repo.GetUserByID(ctx, userID)
 	if err != nil {
-		log.Errorf("[UserService-1] VerifyToken: %v", err)
-		return nil, err
+		log.Errorf("[UserService-1] ChangePassword: %v", err)
+		return err
 	}
 
-	user, err := u.repo.UpdateUserVerified(ctx, verifyToken.UserID)
+	if ok, _ := u.hasher.Verify(currentPassword, user.Password); !ok {
+		err = errors.New("401")
+		log.Errorf("[UserService-2] ChangePassword: %v", err)
+		return err
+	}
+
+	if same, _ := u.hasher.Verify(newPassword, user.Password); same {
+		err = errors.New("422")
+		log.Errorf("[UserService-3] ChangePassword: %v", err)
+		return err
+	}
+
+	if err = u.passwordPolicy.Validate(ctx, newPassword, *user); err != nil {
+		log.Errorf("[UserService-4] ChangePassword: %v", err)
+		return err
+	}
+
+	pwd, err := u.hasher.Hash(newPassword)
 	if err != nil {
-		log.Errorf("[UserService-2] VerifyToken: %v", err)
-		return nil, err
+		log.Errorf("[UserService-5] ChangePassword: %v", err)
+		return err
 	}
 
-	accessToken, err := u.jwtService.GenerateToken(user.ID)
+	changedEmail, err := message.NotificationEvent(user.Email, "your password was changed. If this was not you, please reset your password immediately.")
 	if err != nil {
-		log.Errorf("[UserService-3] VerifyToken: %v", err)
-		return nil, err
+		log.Errorf("[UserService-6] ChangePassword: %v", err)
+		return err
 	}
 
-	sessionData := map[string]interface{}{
-		"user_id":    user.ID,
-		"email":      user.Email,
-		"name":       user.Name,
-		"logged_in":  true,
-		"created_at": time.Now().String(),
-		"token":      token,
+	if err = u.repo.UpdatePasswordByID(ctx, entity.UserEntity{ID: user.ID, Password: pwd}, changedEmail); err != nil {
+		log.Errorf("[UserService-7] ChangePassword: %v", err)
+		return err
+	}
+
+	if err = u.passwordPolicy.Remember(ctx, user.ID, user.Password); err != nil {
+		log.Errorf("[UserService-8] ChangePassword: %v", err)
 	}
 
-	jsonData, err := json.Marshal(sessionData)
+	if err = u.sessionService.RevokeAllUserSessions(ctx, user.ID, familyID); err != nil {
+		log.Errorf("[UserService-9] ChangePassword: %v", err)
+		return err
+	}
+
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:     entity.AuditActionPasswordChanged,
+		TargetType: entity.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+
+	return nil
+}
+
+// VerifyToken implements UserServiceInterface.
+func (u *userService) VerifyToken(ctx context.Context, token string, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	verifyToken, err := u.repoToken.ConsumeToken(ctx, token, entity.TokenTypeEmailVerification)
 	if err != nil {
-		log.Errorf("[UserService-4] Marshal session data: %v", err)
-		return nil, err
+		log.Errorf("[UserService-1] VerifyToken: %v", err)
+		return nil, nil, err
 	}
 
-	redisConn := config.NewRedisClient()
-	err = redisConn.Set(ctx, token, jsonData, time.Hour*24).Err()
+	user, err := u.repo.UpdateUserVerified(ctx, verifyToken.UserID)
 	if err != nil {
-		log.Errorf("[UserService-5] Set session data: %v", err)
-		return nil, err
+		log.Errorf("[UserService-2] VerifyToken: %v", err)
+		return nil, nil, err
 	}
 
-	user.Token = accessToken
-	return user, nil
+	if user.TwoFactorEnabled || user.TwoFactorRequired {
+		session, err := u.twoFactorService.CreateChallenge(ctx, *user)
+		if err != nil {
+			log.Errorf("[UserService-3] VerifyToken: %v", err)
+			return nil, nil, err
+		}
+		return user, session, nil
+	}
 
+	session, err := u.sessionService.CreateSession(ctx, *user, "", client)
+	if err != nil {
+		log.Errorf("[UserService-4] VerifyToken: %v", err)
+		return nil, nil, err
+	}
+
+	return user, session, nil
 }
 
 // ForgotPassword implements UserServiceInterface.
@@ -130,109 +483,288 @@ func (u *userService) ForgotPassword(ctx context.Context, req entity.UserEntity)
 		return err
 	}
 
+	if err = checkTokenCooldown(ctx, u.repoToken, user.ID, entity.TokenTypeForgotPassword, u.cfg.Security.TokenResendCooldown); err != nil {
+		log.Errorf("[UserService-2] ForgotPasswd: %v", err)
+		return err
+	}
+
 	token := uuid.New().String()
 
+	urlForgot := fmt.Sprintf("%s/forgot-password?token=%s", u.cfg.App.UrlForgotPassword, token)
+	messageParam := fmt.Sprintf("please click link below for reset password: %v", urlForgot)
+	resetEmail, err := message.NotificationEvent(user.Email, messageParam)
+	if err != nil {
+		log.Errorf("[UserService-3] ForgotPasswd: %v", err)
+		return err
+	}
+
 	reqEntity := entity.VerificationTokenEntity{
 		UserID:    user.ID,
 		Token:     token,
-		TokenType: "forgot_password",
+		TokenType: entity.TokenTypeForgotPassword,
 	}
 
-	err = u.repoToken.CreateVerificationToken(ctx, reqEntity)
+	err = u.repoToken.CreateVerificationToken(ctx, reqEntity, resetEmail)
 	if err != nil {
-		log.Errorf("[UserService-2] ForgotPasswd: %v", err)
+		log.Errorf("[UserService-4] ForgotPasswd: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ResendVerification implements UserServiceInterface.
+// A new link replaces the previous one, at most once per cooldown period.
+func (u *userService) ResendVerification(ctx context.Context, email string) error {
+	user, err := u.repo.GetUnverifiedUserByEmail(ctx, email)
+	if err != nil {
+		log.Errorf("[UserService-1] ResendVerification: %v", err)
 		return err
 	}
 
-	urlForgot := fmt.Sprintf("%s/forgot-password?token=%s", u.cfg.App.UrlForgotPassword, token)
+	if err = checkTokenCooldown(ctx, u.repoToken, user.ID, entity.TokenTypeEmailVerification, u.cfg.Security.TokenResendCooldown); err != nil {
+		log.Errorf("[UserService-2] ResendVerification: %v", err)
+		return err
+	}
 
-	messageParam := fmt.Sprintf("please click link below for reset password: %v", urlForgot)
-	err = message.PublishMessage(req.Email, messageParam, "forgot-password")
+	token := uuid.New().String()
+	verificationEmail, err := message.NotificationEvent(user.Email, u.verificationMessage(token))
 	if err != nil {
-		log.Errorf("[UserService-3] ForgotPasswd: %v", err)
+		log.Errorf("[UserService-3] ResendVerification: %v", err)
+		return err
+	}
+
+	err = u.repoToken.CreateVerificationToken(ctx, entity.VerificationTokenEntity{
+		UserID:    user.ID,
+		Token:     token,
+		TokenType: entity.TokenTypeEmailVerification,
+	}, verificationEmail)
+	if err != nil {
+		log.Errorf("[UserService-4] ResendVerification: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// checkTokenCooldown returns 429 when a token of the same type was issued
+// to the user less than cooldown ago.
+func checkTokenCooldown(ctx context.Context, repoToken repository.VerificationTokenRepositoryInterface, userID int64, tokenType string, cooldown time.Duration) error {
+	latest, err := repoToken.GetLatestToken(ctx, userID, tokenType)
+	if err != nil {
+		if err.Error() == "404" {
+			return nil
+		}
 		return err
 	}
+
+	if time.Since(latest.CreatedAt) < cooldown {
+		return errors.New("429")
+	}
+
 	return nil
 }
 
+func (u *userService) verificationMessage(token string) string {
+	urlVerify := fmt.Sprintf("%s/verify-account?token=%s", u.cfg.App.UrlVerifyAccount, token)
+	return fmt.Sprintf("please verify your account with click link below: %v", urlVerify)
+}
+
 // CreateUserAccount implements UserServiceInterface.
+// Like every email and SMS this service sends, the verification email goes
+// through the outbox, so it is sent exactly when the account is committed,
+// even if RabbitMQ is down right now.
 func (u *userService) CreateUserAccount(ctx context.Context, req entity.UserEntity) error {
-	passwd, err := conv.HashPassword(req.Password)
-	if err != nil {
+	// Emails are unique regardless of case and stored lower-cased.
+	req.Email = normalizeEmail(req.Email)
+
+	if err := u.passwordPolicy.Validate(ctx, req.Password, entity.UserEntity{Name: req.Name, Email: req.Email}); err != nil {
 		log.Errorf("[UserService-1] CreateUserAccount: %v", err)
 		return err
 	}
 
+	passwd, err := u.hasher.Hash(req.Password)
+	if err != nil {
+		log.Errorf("[UserService-2] CreateUserAccount: %v", err)
+		return err
+	}
+
 	req.Password = passwd
 	token := uuid.New().String()
 	req.Token = token
 
-	err = u.repo.CreateUserAccount(ctx, req)
+	verificationEmail, err := message.NotificationEvent(req.Email, u.verificationMessage(token))
 	if err != nil {
-		log.Errorf("[UserService-2] CreateUserAccount: %v", err)
+		log.Errorf("[UserService-3] CreateUserAccount: %v", err)
 		return err
 	}
 
-	urlVerify := fmt.Sprintf("http://localhost:8080/verify?token=%v", req.Token)
-	messageParam := fmt.Sprintf("please verify your account with click link below: %v", req.Token)
-	err = message.PublishMessage(req.Email, messageParam, urlVerify)
+	err = u.repo.CreateUserAccount(ctx, req, verificationEmail)
 	if err != nil {
-		log.Errorf("[UserService-3] CreateUserAccount: %v", err)
+		log.Errorf("[UserService-4] CreateUserAccount: %v", err)
 		return err
 	}
 
 	return nil
 }
 
-func (u *userService) SignIn(ctx context.Context, req entity.UserEntity) (*entity.UserEntity, string, error) {
+func (u *userService) SignIn(ctx context.Context, req entity.UserEntity, client entity.SessionClientEntity) (*entity.UserEntity, *entity.SessionEntity, error) {
+	if err := u.loginGuard.Check(ctx, req.Email, client.IPAddress); err != nil {
+		log.Errorf("[UserService-1] SignIn: %v", err)
+		return nil, nil, err
+	}
+
 	user, err := u.repo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		log.Errorf("[UserService-1] SignIn: %v", err)
-		return nil, "", err
+		log.Errorf("[UserService-2] SignIn: %v", err)
+		if err.Error() == "404" {
+			u.registerLoginFailure(ctx, req.Email, client.IPAddress)
+		}
+		return nil, nil, err
 	}
 
-	if checkPass := conv.CheckPasswordHash(req.Password, user.Password); !checkPass {
+	if checkPass, _ := u.hasher.Verify(req.Password, user.Password); !checkPass {
 		err = errors.New("invalid password")
-		log.Errorf("[UserService-2] SignIn: %v", err)
-		return nil, "", err
+		log.Errorf("[UserService-3] SignIn: %v", err)
+		u.registerLoginFailure(ctx, user.Email, client.IPAddress)
+		return nil, nil, err
+	}
+
+	if user.IsSuspended {
+		err = errors.New("403")
+		log.Errorf("[UserService-4] SignIn: %v", err)
+		return nil, nil, err
 	}
 
-	token, err := u.jwtService.GenerateToken(user.ID)
+	if err = u.loginGuard.Reset(ctx, user.Email); err != nil {
+		log.Errorf("[UserService-5] SignIn: %v", err)
+		return nil, nil, err
+	}
+
+	u.rehashPassword(ctx, user, req.Password)
+
+	// The session is only issued once the second factor is verified.
+	if user.TwoFactorEnabled || user.TwoFactorRequired {
+		session, err := u.twoFactorService.CreateChallenge(ctx, *user)
+		if err != nil {
+			log.Errorf("[UserService-6] SignIn: %v", err)
+			return nil, nil, err
+		}
+		return user, session, nil
+	}
+
+	session, err := u.sessionService.CreateSession(ctx, *user, "", client)
 	if err != nil {
-		log.Errorf("[UserService-3] SignIn: %v", err)
-		return nil, "", err
+		log.Errorf("[UserService-7] SignIn: %v", err)
+		return nil, nil, err
 	}
 
-	sessionData := map[string]interface{}{
-		"user_id":    user.ID,
-		"email":      user.Email,
-		"name":       user.Name,
-		"logged_in":  true,
-		"created_at": time.Now().String(),
-		"token":      token,
+	return user, session, nil
+}
+
+// rehashPassword upgrades a stored hash made with an old algorithm or cost
+// while the plain password is at hand. Failures only leave the old hash in
+// place, so they are logged and the sign in goes on.
+func (u *userService) rehashPassword(ctx context.Context, user *entity.UserEntity, plain string) {
+	if !u.hasher.NeedsRehash(user.Password) {
+		return
 	}
 
-	jsonData, err := json.Marshal(sessionData)
+	pwd, err := u.hasher.Hash(plain)
 	if err != nil {
-		log.Errorf("[UserService-4] SignIn: %v", err)
-		return nil, "", err
+		log.Errorf("[UserService-1] rehashPassword: %v", err)
+		return
 	}
 
-	redisConn := config.NewRedisClient()
-	err = redisConn.Set(ctx, token, jsonData, time.Hour*24).Err()
+	if err = u.repo.UpdatePasswordByID(ctx, entity.UserEntity{ID: user.ID, Password: pwd}); err != nil {
+		log.Errorf("[UserService-2] rehashPassword: %v", err)
+		return
+	}
+
+	user.Password = pwd
+}
+
+// registerLoginFailure records a failed sign-in and notifies the account
+// owner, if there is one, when it locks the account. Errors are only logged
+// so the caller can still report the original sign-in failure.
+func (u *userService) registerLoginFailure(ctx context.Context, email, ip string) {
+	u.audit.Record(ctx, entity.AuditLogEntity{
+		Action:    entity.AuditActionSignInFailed,
+		IPAddress: ip,
+		Metadata:  map[string]interface{}{"email": email},
+	})
+
+	locked, err := u.loginGuard.RegisterFailure(ctx, email, ip)
 	if err != nil {
-		log.Errorf("[UserService-4] SignIn: %v", err)
-		return nil, "", err
+		log.Errorf("[UserService-1] registerLoginFailure: %v", err)
+		return
+	}
+
+	if !locked {
+		return
+	}
+
+	user, err := u.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err.Error() != "404" {
+			log.Errorf("[UserService-2] registerLoginFailure: %v", err)
+		}
+		return
+	}
+
+	messageParam := fmt.Sprintf("your account has been temporarily locked after too many failed sign in attempts, last attempt from IP %s. If this was not you, please reset your password.", ip)
+	lockedEmail, err := message.NotificationEvent(user.Email, messageParam)
+	if err != nil {
+		log.Errorf("[UserService-3] registerLoginFailure: %v", err)
+		return
+	}
+
+	if err = u.repoOutbox.Create(ctx, entity.OutboxAggregateUser, user.ID, lockedEmail); err != nil {
+		log.Errorf("[UserService-4] registerLoginFailure: %v", err)
+	}
+}
+
+// RefreshToken implements UserServiceInterface.
+// The user is reloaded so role changes, suspension and deletion take effect
+// on the next refresh.
+func (u *userService) RefreshToken(ctx context.Context, refreshToken string, client entity.SessionClientEntity) (*entity.SessionEntity, error) {
+	refresh, err := u.sessionService.ConsumeRefreshToken(ctx, refreshToken)
+	if err != nil {
+		log.Errorf("[UserService-1] RefreshToken: %v", err)
+		return nil, err
+	}
+
+	user, err := u.repo.GetUserByID(ctx, refresh.UserID)
+	if err == nil && user.IsSuspended {
+		err = errors.New("403")
+	}
+	if err != nil {
+		log.Errorf("[UserService-2] RefreshToken: %v", err)
+		if errRevoke := u.sessionService.RevokeFamily(ctx, refresh.FamilyID); errRevoke != nil {
+			log.Errorf("[UserService-3] RefreshToken: %v", errRevoke)
+		}
+		return nil, err
+	}
+
+	session, err := u.sessionService.CreateSession(ctx, *user, refresh.FamilyID, client)
+	if err != nil {
+		log.Errorf("[UserService-4] RefreshToken: %v", err)
+		return nil, err
 	}
 
-	return user, token, nil
+	return session, nil
 }
 
-func NewUserService(repo repository.UserRepositoryInterface, cfg *config.Config, jwtService JwtServiceInterface, repoToken repository.VerificationTokenRepositoryInterface) UserServiceInterface {
+func NewUserService(repo repository.UserRepositoryInterface, cfg *config.Config, sessionService SessionServiceInterface, repoToken repository.VerificationTokenRepositoryInterface, repoOutbox repository.OutboxRepositoryInterface, loginGuard LoginGuardServiceInterface, twoFactorService TwoFactorServiceInterface, passwordPolicy PasswordPolicyServiceInterface, hasher password.Hasher, audit AuditServiceInterface) UserServiceInterface {
 	return &userService{
-		repo:       repo,
-		cfg:        cfg,
-		jwtService: jwtService,
-		repoToken:  repoToken,
+		repo:             repo,
+		cfg:              cfg,
+		sessionService:   sessionService,
+		repoToken:        repoToken,
+		repoOutbox:       repoOutbox,
+		loginGuard:       loginGuard,
+		twoFactorService: twoFactorService,
+		passwordPolicy:   passwordPolicy,
+		hasher:           hasher,
+		audit:            audit,
 	}
 }
@@ -1,21 +0,0 @@
-package conv
-
-import (
-	"strconv"
-
-	"golang.org/x/crypto/bcrypt"
-)
-
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
-}
-
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
-
-func LatLngToString(f float64) string {
-	return strconv.FormatFloat(f, 'g', -1, 64)
-}
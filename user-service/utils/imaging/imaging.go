@@ -0,0 +1,148 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupported = errors.New("image must be a JPEG, PNG or WebP")
+	ErrTooLarge    = errors.New("image dimensions are too large")
+)
+
+const jpegQuality = 85
+
+// Variant is one resized copy of an upload. Size bounds the longest side;
+// Square variants are center cropped to Size x Size. Images are never
+// enlarged.
+type Variant struct {
+	Name   string
+	Size   int
+	Square bool
+}
+
+type Output struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Process checks by content that data is a JPEG, PNG or WebP image, applies
+// its EXIF orientation and encodes every variant. Encoding from decoded
+// pixels drops EXIF and any other metadata. Images with transparency are
+// written as PNG, everything else as JPEG, so all variants of one upload
+// share a format. maxPixels rejects images that would take too much memory
+// to decode.
+func Process(data []byte, maxPixels int, variants []Variant) ([]Output, error) {
+	contentType := http.DetectContentType(data)
+
+	var (
+		decodeConfig func([]byte) (image.Config, error)
+		decode       func([]byte) (image.Image, error)
+	)
+
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+	case "image/png":
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+	case "image/webp":
+		decodeConfig = func(b []byte) (image.Config, error) { return webp.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(b)) }
+	default:
+		return nil, ErrUnsupported
+	}
+
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	src := toNRGBA(img)
+	if contentType == "image/jpeg" {
+		src = orient(src, jpegOrientation(data))
+	}
+
+	outContentType, extension := "image/jpeg", ".jpg"
+	if !src.Opaque() {
+		outContentType, extension = "image/png", ".png"
+	}
+
+	outputs := []Output{}
+	for _, variant := range variants {
+		resized := resize(src, variant)
+
+		buf := new(bytes.Buffer)
+		if outContentType == "image/png" {
+			err = png.Encode(buf, resized)
+		} else {
+			err = jpeg.Encode(buf, resized, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		outputs = append(outputs, Output{
+			Name:        variant.Name,
+			Data:        buf.Bytes(),
+			ContentType: outContentType,
+			Extension:   extension,
+			Width:       resized.Bounds().Dx(),
+			Height:      resized.Bounds().Dy(),
+		})
+	}
+
+	return outputs, nil
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+func resize(src *image.NRGBA, variant Variant) *image.NRGBA {
+	srcRect := src.Bounds()
+	w, h := srcRect.Dx(), srcRect.Dy()
+
+	var dstW, dstH int
+	if variant.Square {
+		side := min(w, h)
+		srcRect = image.Rect((w-side)/2, (h-side)/2, (w-side)/2+side, (h-side)/2+side)
+		dstW = min(variant.Size, side)
+		dstH = dstW
+	} else {
+		dstW, dstH = w, h
+		if longest := max(w, h); longest > variant.Size {
+			dstW = max(1, w*variant.Size/longest)
+			dstH = max(1, h*variant.Size/longest)
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, xdraw.Src, nil)
+	return dst
+}
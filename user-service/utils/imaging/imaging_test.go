@@ -0,0 +1,256 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// withOrientation inserts an EXIF segment with the given orientation right
+// after the start of a JPEG.
+func withOrientation(t *testing.T, data []byte, order binary.ByteOrder, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(2+len(segment)))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, img, nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, w, h int, alpha uint8) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 0x80, G: 0x40, B: 0x20, A: alpha})
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	data := encodeJPEG(t, 4, 2)
+
+	if got := jpegOrientation(data); got != 1 {
+		t.Fatalf("orientation without EXIF = %d, want 1", got)
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := uint16(1); orientation <= 8; orientation++ {
+			if got := jpegOrientation(withOrientation(t, data, order, orientation)); got != int(orientation) {
+				t.Errorf("%v orientation %d read as %d", order, orientation, got)
+			}
+		}
+	}
+
+	if got := jpegOrientation(withOrientation(t, data, binary.BigEndian, 9)); got != 1 {
+		t.Errorf("invalid orientation read as %d, want 1", got)
+	}
+}
+
+// TestOrient turns a stored 3x2 image
+//
+//	a b c
+//	d e f
+//
+// upright for every EXIF orientation.
+func TestOrient(t *testing.T) {
+	tests := []struct {
+		orientation int
+		want        []string
+	}{
+		{orientation: 1, want: []string{"abc", "def"}},
+		{orientation: 2, want: []string{"cba", "fed"}},
+		{orientation: 3, want: []string{"fed", "cba"}},
+		{orientation: 4, want: []string{"def", "abc"}},
+		{orientation: 5, want: []string{"ad", "be", "cf"}},
+		{orientation: 6, want: []string{"da", "eb", "fc"}},
+		{orientation: 7, want: []string{"fc", "eb", "da"}},
+		{orientation: 8, want: []string{"cf", "be", "ad"}},
+	}
+
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i, label := range "abcdef" {
+		src.SetNRGBA(i%3, i/3, color.NRGBA{R: uint8(label), A: 0xFF})
+	}
+
+	for _, tt := range tests {
+		dst := orient(src, tt.orientation)
+
+		var got []string
+		for y := 0; y < dst.Bounds().Dy(); y++ {
+			row := ""
+			for x := 0; x < dst.Bounds().Dx(); x++ {
+				row += string(rune(dst.NRGBAAt(x, y).R))
+			}
+			got = append(got, row)
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("orientation %d: got %v, want %v", tt.orientation, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("orientation %d: got %v, want %v", tt.orientation, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	data := encodeJPEG(t, 40, 20)
+
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		outputs, err := Process(withOrientation(t, data, binary.BigEndian, orientation), 10000, []Variant{{Name: "original", Size: 100}})
+		if err != nil {
+			t.Fatalf("orientation %d: %v", orientation, err)
+		}
+
+		wantW, wantH := 40, 20
+		if orientation >= 5 {
+			wantW, wantH = 20, 40
+		}
+		if outputs[0].Width != wantW || outputs[0].Height != wantH {
+			t.Errorf("orientation %d: got %dx%d, want %dx%d", orientation, outputs[0].Width, outputs[0].Height, wantW, wantH)
+		}
+	}
+}
+
+func TestProcessResizes(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		variant       Variant
+		wantW, wantH  int
+	}{
+		{name: "landscape", width: 400, height: 200, variant: Variant{Size: 100}, wantW: 100, wantH: 50},
+		{name: "portrait", width: 150, height: 600, variant: Variant{Size: 200}, wantW: 50, wantH: 200},
+		{name: "smaller than size", width: 80, height: 60, variant: Variant{Size: 200}, wantW: 80, wantH: 60},
+		{name: "square crop", width: 400, height: 200, variant: Variant{Size: 64, Square: true}, wantW: 64, wantH: 64},
+		{name: "square smaller than size", width: 40, height: 30, variant: Variant{Size: 64, Square: true}, wantW: 30, wantH: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs, err := Process(encodePNG(t, tt.width, tt.height, 0xFF), 1<<20, []Variant{tt.variant})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+
+			out := outputs[0]
+			if out.Width != tt.wantW || out.Height != tt.wantH {
+				t.Fatalf("got %dx%d, want %dx%d", out.Width, out.Height, tt.wantW, tt.wantH)
+			}
+
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(out.Data))
+			if err != nil {
+				t.Fatalf("decode output: %v", err)
+			}
+			if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Fatalf("encoded %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestProcessOutputFormat(t *testing.T) {
+	tests := []struct {
+		name            string
+		alpha           uint8
+		wantContentType string
+		wantExtension   string
+	}{
+		{name: "opaque", alpha: 0xFF, wantContentType: "image/jpeg", wantExtension: ".jpg"},
+		{name: "transparent", alpha: 0x80, wantContentType: "image/png", wantExtension: ".png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs, err := Process(encodePNG(t, 10, 10, tt.alpha), 1<<20, []Variant{{Name: "a", Size: 10}, {Name: "b", Size: 5}})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+
+			for _, out := range outputs {
+				if out.ContentType != tt.wantContentType || out.Extension != tt.wantExtension {
+					t.Errorf("%s: got %s %s, want %s %s", out.Name, out.ContentType, out.Extension, tt.wantContentType, tt.wantExtension)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	gifData := new(bytes.Buffer)
+	if err := gif.Encode(gifData, image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White}), nil); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		maxPixels int
+		want      error
+	}{
+		{name: "gif", data: gifData.Bytes(), maxPixels: 1 << 20, want: ErrUnsupported},
+		{name: "text", data: []byte("not an image"), maxPixels: 1 << 20, want: ErrUnsupported},
+		{name: "empty", data: nil, maxPixels: 1 << 20, want: ErrUnsupported},
+		{name: "too many pixels", data: encodePNG(t, 100, 100, 0xFF), maxPixels: 100*100 - 1, want: ErrTooLarge},
+		{name: "too many pixels jpeg", data: encodeJPEG(t, 100, 100), maxPixels: 100*100 - 1, want: ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data, tt.maxPixels, []Variant{{Name: "original", Size: 100}}); !errors.Is(err, tt.want) {
+				t.Fatalf("Process error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type BreachChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+type rangeFileChecker struct {
+	dir string
+}
+
+// IsBreached implements BreachChecker.
+func (r *rangeFileChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(r.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// NewRangeFileChecker checks passwords against a local copy of a breached
+// password corpus split by SHA-1 prefix, the same layout as the k-anonymity
+// range API: dir/<first 5 hex chars>.txt holding "SUFFIX:COUNT" lines.
+// Only the prefix file is read, so the full corpus never has to be loaded.
+func NewRangeFileChecker(dir string) BreachChecker {
+	return &rangeFileChecker{dir: dir}
+}
@@ -0,0 +1,211 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// Hasher hashes passwords into a self-describing encoded string, so the
+// algorithm and its parameters can change without invalidating old hashes.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	// Matches reports whether encoded was produced by this algorithm.
+	Matches(encoded string) bool
+	// NeedsRehash reports whether encoded uses other parameters than the
+	// hasher would use now.
+	NeedsRehash(encoded string) bool
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// Hash implements Hasher.
+func (b *bcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	return string(bytes), err
+}
+
+// Verify implements Hasher.
+func (b *bcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Matches implements Hasher.
+func (b *bcryptHasher) Matches(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash implements Hasher.
+func (b *bcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.cost
+}
+
+func NewBcryptHasher(cost int) Hasher {
+	return &bcryptHasher{cost: cost}
+}
+
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+// Hash implements Hasher.
+// The result uses the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func (a *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		a.params.Memory,
+		a.params.Iterations,
+		a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify implements Hasher.
+func (a *argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// Matches implements Hasher.
+func (a *argon2idHasher) Matches(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// NeedsRehash implements Hasher.
+func (a *argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != a.params.Memory ||
+		params.Iterations != a.params.Iterations ||
+		params.Parallelism != a.params.Parallelism ||
+		uint32(len(salt)) != a.params.SaltLength ||
+		uint32(len(key)) != a.params.KeyLength
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	params := Argon2idParams{}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+func NewArgon2idHasher(params Argon2idParams) Hasher {
+	if params.SaltLength == 0 {
+		params.SaltLength = 16
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = 32
+	}
+	return &argon2idHasher{params: params}
+}
+
+type multiHasher struct {
+	primary Hasher
+	legacy  []Hasher
+}
+
+// Hash implements Hasher.
+func (m *multiHasher) Hash(password string) (string, error) {
+	return m.primary.Hash(password)
+}
+
+// Verify implements Hasher.
+func (m *multiHasher) Verify(password, encoded string) (bool, error) {
+	for _, hasher := range append([]Hasher{m.primary}, m.legacy...) {
+		if hasher.Matches(encoded) {
+			return hasher.Verify(password, encoded)
+		}
+	}
+
+	return false, ErrUnknownHashFormat
+}
+
+// Matches implements Hasher.
+func (m *multiHasher) Matches(encoded string) bool {
+	for _, hasher := range append([]Hasher{m.primary}, m.legacy...) {
+		if hasher.Matches(encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsRehash implements Hasher.
+func (m *multiHasher) NeedsRehash(encoded string) bool {
+	return !m.primary.Matches(encoded) || m.primary.NeedsRehash(encoded)
+}
+
+// NewHasher hashes with primary and still verifies hashes made by any of
+// the legacy hashers, which NeedsRehash then reports as outdated.
+func NewHasher(primary Hasher, legacy ...Hasher) Hasher {
+	return &multiHasher{primary: primary, legacy: legacy}
+}
@@ -0,0 +1,171 @@
+package password
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	TagMinLength        = "min_length"
+	TagCharacterClasses = "character_classes"
+	TagPersonalInfo     = "personal_info"
+	TagHistory          = "history"
+	TagBreached         = "breached"
+)
+
+// Messages holds the text for each violation. {0} is the field name and {1}
+// the rule parameter, matching the validator translation placeholders.
+var Messages = map[string]string{
+	TagMinLength:        "{0} must be at least {1} characters long",
+	TagCharacterClasses: "{0} must contain at least {1} of: lowercase letters, uppercase letters, digits and symbols",
+	TagPersonalInfo:     "{0} must not contain your name or email",
+	TagHistory:          "{0} must not be one of your last {1} passwords",
+	TagBreached:         "{0} has appeared in a data breach, please choose a different one",
+}
+
+// Subject is what a rule may know about the password owner. Rules that need
+// a field which is empty are skipped, so a policy can also run on a bare
+// password, e.g. from request validation.
+type Subject struct {
+	Email string
+	Name  string
+	// History holds hashes of the current and previous passwords, newest first.
+	History []string
+}
+
+// Violation is returned when a password breaks a rule.
+type Violation struct {
+	Tag   string
+	Param string
+}
+
+func (v *Violation) Error() string {
+	return Message(v.Tag, "password", v.Param)
+}
+
+func Message(tag, field, param string) string {
+	return strings.NewReplacer("{0}", field, "{1}", param).Replace(Messages[tag])
+}
+
+type Rule interface {
+	Check(ctx context.Context, password string, subject Subject) error
+}
+
+type RuleFunc func(ctx context.Context, password string, subject Subject) error
+
+func (f RuleFunc) Check(ctx context.Context, password string, subject Subject) error {
+	return f(ctx, password, subject)
+}
+
+// Policy runs its rules in order and returns the first violation.
+type Policy struct {
+	rules []Rule
+}
+
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+func (p *Policy) Check(ctx context.Context, password string, subject Subject) error {
+	for _, rule := range p.rules {
+		if err := rule.Check(ctx, password, subject); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func MinLength(n int) Rule {
+	return RuleFunc(func(ctx context.Context, password string, subject Subject) error {
+		if len([]rune(password)) < n {
+			return &Violation{Tag: TagMinLength, Param: strconv.Itoa(n)}
+		}
+		return nil
+	})
+}
+
+// CharacterClasses requires at least n of lowercase, uppercase, digits and
+// symbols.
+func CharacterClasses(n int) Rule {
+	return RuleFunc(func(ctx context.Context, password string, subject Subject) error {
+		var lower, upper, digit, symbol bool
+		for _, r := range password {
+			switch {
+			case unicode.IsLower(r):
+				lower = true
+			case unicode.IsUpper(r):
+				upper = true
+			case unicode.IsDigit(r):
+				digit = true
+			default:
+				symbol = true
+			}
+		}
+
+		classes := 0
+		for _, ok := range []bool{lower, upper, digit, symbol} {
+			if ok {
+				classes++
+			}
+		}
+
+		if classes < n {
+			return &Violation{Tag: TagCharacterClasses, Param: strconv.Itoa(n)}
+		}
+		return nil
+	})
+}
+
+// NoPersonalInfo rejects passwords containing the email local part or any
+// part of the name. Parts shorter than 3 characters are ignored.
+func NoPersonalInfo() Rule {
+	return RuleFunc(func(ctx context.Context, password string, subject Subject) error {
+		lowered := strings.ToLower(password)
+
+		parts := strings.Fields(strings.ToLower(subject.Name))
+		if local, _, found := strings.Cut(strings.ToLower(subject.Email), "@"); found {
+			parts = append(parts, local)
+		}
+
+		for _, part := range parts {
+			if len(part) >= 3 && strings.Contains(lowered, part) {
+				return &Violation{Tag: TagPersonalInfo}
+			}
+		}
+		return nil
+	})
+}
+
+// History rejects a password matching any of the last n hashes.
+func History(n int, hasher Hasher) Rule {
+	return RuleFunc(func(ctx context.Context, password string, subject Subject) error {
+		history := subject.History
+		if len(history) > n {
+			history = history[:n]
+		}
+
+		for _, hash := range history {
+			if ok, _ := hasher.Verify(password, hash); ok {
+				return &Violation{Tag: TagHistory, Param: strconv.Itoa(n)}
+			}
+		}
+		return nil
+	})
+}
+
+func Breached(checker BreachChecker) Rule {
+	return RuleFunc(func(ctx context.Context, password string, subject Subject) error {
+		breached, err := checker.IsBreached(ctx, password)
+		if err != nil {
+			return err
+		}
+
+		if breached {
+			return &Violation{Tag: TagBreached}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,59 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultCountryCode is assumed for numbers written in national format.
+const DefaultCountryCode = "62"
+
+var ErrInvalid = errors.New("invalid phone number")
+
+// Normalize converts a phone number to E.164 (+<country><number>).
+//
+// Separators are ignored. Numbers without a country code are read as
+// Indonesian, so 0812-3456-7890, 62812 3456 7890 and 812 3456 7890 all
+// become +6281234567890.
+func Normalize(raw string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalid
+		}
+	}
+
+	digits := b.String()
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0"):
+		digits = DefaultCountryCode + digits[1:]
+	case strings.HasPrefix(digits, DefaultCountryCode):
+	default:
+		digits = DefaultCountryCode + digits
+	}
+
+	if strings.HasPrefix(digits, DefaultCountryCode) {
+		// Indonesian numbers drop the trunk 0; +62 0812... is a common typo.
+		national := strings.TrimPrefix(digits[len(DefaultCountryCode):], "0")
+		if len(national) < 8 || len(national) > 12 {
+			return "", ErrInvalid
+		}
+		digits = DefaultCountryCode + national
+	}
+
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalid
+	}
+
+	return "+" + digits, nil
+}
@@ -1,12 +1,16 @@
 package validator
 
 import (
+	"context"
 	"errors"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/gommon/log"
+
+	"user-service/utils/password"
+	"user-service/utils/phone"
 )
 
 type Validator struct {
@@ -25,10 +29,16 @@ func NewValidator() *Validator {
 
 	validate := validator.New()
 
-	return &Validator{
+	v := &Validator{
 		Validator:  validate,
 		Translator: trans,
 	}
+
+	if err := v.registerPhone(); err != nil {
+		log.Fatalf("[NewValidator] %v", err)
+	}
+
+	return v
 }
 
 func (v *Validator) Validate(i interface{}) error {
@@ -42,3 +52,64 @@ func (v *Validator) Validate(i interface{}) error {
 
 	return nil
 }
+
+// RegisterPasswordPolicy adds the `password` tag. Only the rules that work
+// on the bare password apply here; the service checks the full policy.
+func (v *Validator) RegisterPasswordPolicy(policy *password.Policy) error {
+	err := v.Validator.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return policy.Check(context.Background(), fl.Field().String(), password.Subject{}) == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return v.Validator.RegisterTranslation("password", v.Translator,
+		func(trans ut.Translator) error {
+			for tag, text := range password.Messages {
+				if err := trans.Add("password_"+tag, text, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(trans ut.Translator, fe validator.FieldError) string {
+			value, _ := fe.Value().(string)
+
+			violation := &password.Violation{}
+			if err := policy.Check(context.Background(), value, password.Subject{}); !errors.As(err, &violation) {
+				return fe.Error()
+			}
+
+			text, err := trans.T("password_"+violation.Tag, fe.Field(), violation.Param)
+			if err != nil {
+				return fe.Error()
+			}
+			return text
+		},
+	)
+}
+
+// registerPhone adds the `phone` tag, which accepts anything phone.Normalize
+// can turn into an E.164 number.
+func (v *Validator) registerPhone() error {
+	err := v.Validator.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		_, err := phone.Normalize(fl.Field().String())
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return v.Validator.RegisterTranslation("phone", v.Translator,
+		func(trans ut.Translator) error {
+			return trans.Add("phone", "{0} must be a valid phone number", true)
+		},
+		func(trans ut.Translator, fe validator.FieldError) string {
+			text, err := trans.T("phone", fe.Field())
+			if err != nil {
+				return fe.Error()
+			}
+			return text
+		},
+	)
+}